@@ -10,8 +10,10 @@ import (
 // @title Subscription Aggregation Service API
 // @version 1.0
 // @description This is a service for managing user subscriptions
+// @description Errors are returned as application/problem+json (RFC 7807) with a stable error code
+// @description and per-field errors for validation failures.
 // @host localhost:8080
-// @BasePath /api/subs
+// @BasePath /api
 func main() {
 	cfg := config.GetConfig()
 
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/maYkiss56/subscription-aggregation-service/internal/config"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/repository"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/service"
+	"github.com/maYkiss56/subscription-aggregation-service/pkg/client/postgresql"
+)
+
+// Разовая команда после изменения правил нормализации имён сервисов: пересчитывает нормализованные
+// имена и псевдонимы каталога и сливает сервисы, имена которых стали совпадать
+func main() {
+	cfg := config.GetConfig()
+
+	pgClient, err := postgresql.New(context.Background(), postgresql.PgConfig{
+		Username: cfg.Postgres.Username,
+		Password: cfg.Postgres.Password,
+		Host:     cfg.Postgres.Host,
+		Port:     cfg.Postgres.Port,
+		Database: cfg.Postgres.Database,
+		SSLMode:  cfg.Postgres.SSLMode,
+		PoolSize: cfg.Postgres.PoolSize,
+	})
+	if err != nil {
+		log.Fatalf("failed to create postgres client: %v", err)
+	}
+	defer pgClient.Close()
+
+	catalogService := service.NewCatalogService(repository.NewServiceRepository(pgClient))
+
+	renamed, merged, err := catalogService.RenormalizeServiceNames(context.Background())
+	if err != nil {
+		log.Fatalf("failed to renormalize service names: %v", err)
+	}
+
+	log.Printf("renormalized %d service names, merged %d duplicate services", renamed, merged)
+}
@@ -10,6 +10,9 @@ import (
 	"time"
 
 	"github.com/maYkiss56/subscription-aggregation-service/internal/config"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/delivery/api/catalog"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/delivery/api/rate"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/delivery/api/stats"
 	"github.com/maYkiss56/subscription-aggregation-service/internal/delivery/api/sub"
 	"github.com/maYkiss56/subscription-aggregation-service/internal/repository"
 	"github.com/maYkiss56/subscription-aggregation-service/internal/server"
@@ -18,9 +21,10 @@ import (
 )
 
 type App struct {
-	cfg      *config.Config
-	server   *server.Server
-	pgClient *postgresql.PostgresClient
+	cfg        *config.Config
+	server     *server.Server
+	pgClient   *postgresql.PostgresClient
+	subService *service.SubService
 }
 
 func New(cfg *config.Config) (*App, error) {
@@ -45,15 +49,45 @@ func New(cfg *config.Config) (*App, error) {
 
 	subHandler := sub.New(subService)
 
+	rateRepo := repository.NewRateRepository(pgClient)
+
+	rateService := service.NewRateService(rateRepo)
+
+	if cfg.Rates.File != "" {
+		loaded, err := rateService.LoadRatesFile(context.Background(), cfg.Rates.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load exchange rates: %w", err)
+		}
+		log.Printf("loaded %d exchange rates from %s", loaded, cfg.Rates.File)
+	}
+
+	rateHandler := rate.New(rateService)
+
+	serviceRepo := repository.NewServiceRepository(pgClient)
+
+	catalogService := service.NewCatalogService(serviceRepo)
+
+	catalogHandler := catalog.New(catalogService)
+
+	statsRepo := repository.NewStatsRepository(pgClient)
+
+	statsService := service.NewStatsService(statsRepo)
+
+	statsHandler := stats.New(statsService)
+
 	router := sub.NewRouter(subHandler)
+	router.Mount("/api/rates", rate.NewRouter(rateHandler))
+	router.Mount("/api/services", catalog.NewRouter(catalogHandler))
+	router.Mount("/api/stats", stats.NewRouter(statsHandler))
 
 	srv := server.New(cfg)
 	srv.SetHandler(router)
 
 	return &App{
-		cfg:      cfg,
-		server:   srv,
-		pgClient: pgClient,
+		cfg:        cfg,
+		server:     srv,
+		pgClient:   pgClient,
+		subService: subService,
 	}, nil
 }
 
@@ -65,6 +99,7 @@ func (a *App) Run() error {
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
 	go a.databaseHealthCheck(ctx)
+	go a.purgeDeletedSubs(ctx)
 
 	go func() {
 		if err := a.server.Start(ctx); err != nil {
@@ -108,3 +143,28 @@ func (a *App) databaseHealthCheck(ctx context.Context) {
 		}
 	}
 }
+
+func (a *App) purgeDeletedSubs(ctx context.Context) {
+	if a.cfg.Retention.PurgeInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(a.cfg.Retention.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			purged, err := a.subService.PurgeDeletedSubs(ctx, a.cfg.Retention.SoftDeleted)
+			if err != nil {
+				log.Printf("failed to purge deleted subscriptions: %v", err)
+				continue
+			}
+			if purged > 0 {
+				log.Printf("purged %d deleted subscriptions", purged)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -26,6 +26,16 @@ type Config struct {
 		SSLMode  string `yaml:"sslmode"`
 		PoolSize int    `yaml:"pool_size"`
 	} `yaml:"postgres"`
+
+	Retention struct {
+		SoftDeleted   time.Duration `yaml:"soft_deleted" env-default:"720h"`
+		PurgeInterval time.Duration `yaml:"purge_interval" env-default:"24h"`
+	} `yaml:"retention"`
+
+	Rates struct {
+		// File is CSV or JSON file with exchange rates loaded on start
+		File string `yaml:"file" env:"RATES_FILE"`
+	} `yaml:"rates"`
 }
 
 var (
@@ -0,0 +1,241 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/delivery/api"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/domain"
+)
+
+const ErrInvalidServiceID = "invalid service id"
+
+type CatalogService interface {
+	CreateService(ctx context.Context, service *domain.Service) (*domain.Service, error)
+	GetServices(ctx context.Context) ([]*domain.Service, error)
+	GetServiceByID(ctx context.Context, id uuid.UUID) (*domain.Service, error)
+	UpdateService(ctx context.Context, id uuid.UUID, req *domain.UpdateServiceRequest) (*domain.Service, error)
+	DeleteService(ctx context.Context, id uuid.UUID) error
+	SuggestServices(ctx context.Context, query string, limit int) ([]domain.ServiceSuggestion, error)
+}
+
+type HandlerCatalog struct {
+	service CatalogService
+}
+
+func New(service CatalogService) *HandlerCatalog {
+	return &HandlerCatalog{
+		service: service,
+	}
+}
+
+// CreateService godoc
+// @Summary Add service to the catalog
+// @Description Add service subscriptions are matched to by name or alias, ignoring case and extra spaces.
+// @Description Returns 409 if the name or an alias is already used by another service.
+// @Tags services
+// @Accept  json
+// @Produce  json
+// @Param input body domain.CreateServiceRequest true "Create service"
+// @Success 201 {object} domain.ServiceResponse "Created service"
+// @Failure 400 {object} api.Problem "Invalid input"
+// @Failure 409 {object} api.Problem "Name or alias is already taken"
+// @Failure 422 {object} api.Problem "Validation failed"
+// @Failure 503 {object} api.Problem "Database unavailable"
+// @Failure 500 {object} api.Problem "Internal server error"
+// @Router /services/create [post]
+func (h *HandlerCatalog) CreateService(w http.ResponseWriter, r *http.Request) {
+	var req domain.CreateServiceRequest
+	defer r.Body.Close()
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteBodyError(w, r, err)
+		return
+	}
+
+	newService, err := domain.NewService(req.Name, req.Aliases, req.Category, req.Homepage, req.DefaultPrice)
+	if err != nil {
+		api.WriteServiceError(w, r, err)
+		return
+	}
+
+	created, err := h.service.CreateService(r.Context(), newService)
+	if err != nil {
+		api.WriteServiceError(w, r, err)
+		return
+	}
+
+	api.WriteJSON(w, http.StatusCreated, domain.ConvertServiceToResponse(created))
+}
+
+// GetServices godoc
+// @Summary Get services catalog
+// @Description Get all services of the catalog ordered by name
+// @Tags services
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} domain.ServiceResponse "Services"
+// @Failure 503 {object} api.Problem "Database unavailable"
+// @Failure 500 {object} api.Problem "Internal server error"
+// @Router /services [get]
+func (h *HandlerCatalog) GetServices(w http.ResponseWriter, r *http.Request) {
+	services, err := h.service.GetServices(r.Context())
+	if err != nil {
+		api.WriteServiceError(w, r, err)
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, domain.ConvertServicesToResponse(services))
+}
+
+// GetServiceByID godoc
+// @Summary Get service by ID
+// @Description Get single service of the catalog by its ID
+// @Tags services
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Service ID"
+// @Success 200 {object} domain.ServiceResponse "Service"
+// @Failure 400 {object} api.Problem "Invalid service ID"
+// @Failure 404 {object} api.Problem "Service not found"
+// @Failure 503 {object} api.Problem "Database unavailable"
+// @Failure 500 {object} api.Problem "Internal server error"
+// @Router /services/id/{id} [get]
+func (h *HandlerCatalog) GetServiceByID(w http.ResponseWriter, r *http.Request) {
+	serviceID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		api.WriteFieldError(w, r, "id", ErrInvalidServiceID)
+		return
+	}
+
+	service, err := h.service.GetServiceByID(r.Context(), serviceID)
+	if err != nil {
+		api.WriteServiceError(w, r, err)
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, domain.ConvertServiceToResponse(service))
+}
+
+// UpdateService godoc
+// @Summary Update service
+// @Description Update service of the catalog with JSON Merge Patch (RFC 7396): omitted fields are not changed,
+// @Description null clears aliases, category, homepage and default_price. Aliases are replaced as a whole.
+// @Description Subscriptions keep their service, only names sent later are matched by the new name and aliases.
+// @Tags services
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Service ID"
+// @Param input body domain.UpdateServiceRequest true "Update service"
+// @Success 200 {object} domain.ServiceResponse "Updated service"
+// @Failure 400 {object} api.Problem "Invalid input"
+// @Failure 404 {object} api.Problem "Service not found"
+// @Failure 409 {object} api.Problem "Name or alias is already taken"
+// @Failure 422 {object} api.Problem "Validation failed"
+// @Failure 503 {object} api.Problem "Database unavailable"
+// @Failure 500 {object} api.Problem "Internal server error"
+// @Router /services/update/{id} [patch]
+func (h *HandlerCatalog) UpdateService(w http.ResponseWriter, r *http.Request) {
+	serviceID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		api.WriteFieldError(w, r, "id", ErrInvalidServiceID)
+		return
+	}
+
+	var req domain.UpdateServiceRequest
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteBodyError(w, r, err)
+		return
+	}
+
+	updated, err := h.service.UpdateService(r.Context(), serviceID, &req)
+	if err != nil {
+		api.WriteServiceError(w, r, err)
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, domain.ConvertServiceToResponse(updated))
+}
+
+// DeleteService godoc
+// @Summary Delete service
+// @Description Delete service from the catalog, services with subscriptions (including deleted ones) cannot be deleted
+// @Tags services
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Service ID"
+// @Success 204 "Service deleted"
+// @Failure 400 {object} api.Problem "Invalid service ID"
+// @Failure 404 {object} api.Problem "Service not found"
+// @Failure 409 {object} api.Problem "Service is used by subscriptions"
+// @Failure 503 {object} api.Problem "Database unavailable"
+// @Failure 500 {object} api.Problem "Internal server error"
+// @Router /services/delete/{id} [delete]
+func (h *HandlerCatalog) DeleteService(w http.ResponseWriter, r *http.Request) {
+	serviceID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		api.WriteFieldError(w, r, "id", ErrInvalidServiceID)
+		return
+	}
+
+	if err := h.service.DeleteService(r.Context(), serviceID); err != nil {
+		api.WriteServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SuggestServices godoc
+// @Summary Suggest services
+// @Description Autocomplete services of the catalog by name or alias. The query is normalized like subscription names:
+// @Description case, extra spaces, punctuation and diacritics are ignored and common abbreviations are expanded.
+// @Description Services whose name or alias starts with the query go first, then services with similar names by trigram similarity.
+// @Tags services
+// @Accept  json
+// @Produce  json
+// @Param q query string true "Service name or its part"
+// @Param limit query int false "Number of suggestions (1-50, default 10)"
+// @Success 200 {array} domain.ServiceSuggestionResponse "Suggested services"
+// @Failure 400 {object} api.Problem "Invalid query parameters"
+// @Failure 503 {object} api.Problem "Database unavailable"
+// @Failure 500 {object} api.Problem "Internal server error"
+// @Router /services/suggest [get]
+func (h *HandlerCatalog) SuggestServices(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	verr := domain.NewValidationError()
+
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		verr.Add("q", "must not be empty")
+	}
+
+	limit := domain.DefaultSuggestLimit
+	if v := query.Get("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 || parsed > domain.MaxSuggestLimit {
+			verr.Add("limit", "must be an integer between 1 and "+strconv.Itoa(domain.MaxSuggestLimit))
+		} else {
+			limit = parsed
+		}
+	}
+
+	if len(verr.Fields) > 0 {
+		api.WriteInvalidParams(w, r, verr)
+		return
+	}
+
+	suggestions, err := h.service.SuggestServices(r.Context(), q, limit)
+	if err != nil {
+		api.WriteServiceError(w, r, err)
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, domain.ConvertServiceSuggestionsToResponse(suggestions))
+}
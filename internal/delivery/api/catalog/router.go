@@ -0,0 +1,21 @@
+package catalog
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/delivery/api"
+)
+
+func NewRouter(services *HandlerCatalog) chi.Router {
+	r := chi.NewRouter()
+	r.NotFound(api.NotFound)
+	r.MethodNotAllowed(api.MethodNotAllowed)
+
+	r.Get("/", services.GetServices)
+	r.Get("/id/{id}", services.GetServiceByID)
+	r.Get("/suggest", services.SuggestServices)
+	r.Post("/create", services.CreateService)
+	r.Patch("/update/{id}", services.UpdateService)
+	r.Delete("/delete/{id}", services.DeleteService)
+
+	return r
+}
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/maYkiss56/subscription-aggregation-service/internal/utils"
+)
+
+// ParseDateFormat разбирает формат дат ответа из параметра date_format или заголовка X-Date-Format,
+// пустой формат означает формат, в котором даты передал клиент
+func ParseDateFormat(r *http.Request) (utils.DateFormat, error) {
+	v := r.URL.Query().Get("date_format")
+	if v == "" {
+		v = r.Header.Get("X-Date-Format")
+	}
+	if v == "" {
+		return "", nil
+	}
+
+	format := utils.DateFormat(strings.ToUpper(strings.TrimSpace(v)))
+	if !format.Valid() {
+		return "", errors.New("must be one of YYYY-MM-DD, YYYY-MM, MM-YYYY")
+	}
+
+	return format, nil
+}
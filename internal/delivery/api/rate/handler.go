@@ -0,0 +1,85 @@
+package rate
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/maYkiss56/subscription-aggregation-service/internal/delivery/api"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/domain"
+)
+
+type RateService interface {
+	LoadRates(ctx context.Context, r io.Reader, format domain.RatesFormat) (int, error)
+	GetRates(ctx context.Context, currency string) ([]domain.ExchangeRate, error)
+}
+
+type HandlerRate struct {
+	service RateService
+}
+
+func New(service RateService) *HandlerRate {
+	return &HandlerRate{
+		service: service,
+	}
+}
+
+// LoadRates godoc
+// @Summary Load exchange rates
+// @Description Load exchange rates as JSON array or CSV with columns currency,date,rate (for administrators).
+// @Description Rate is the price of one unit of currency in RUB effective from date, existing rates for the same date are replaced.
+// @Tags rates
+// @Accept  json,text/csv
+// @Produce  json
+// @Param input body []domain.RateRequest true "Exchange rates"
+// @Success 200 {object} domain.RatesResponse "Number of loaded rates"
+// @Failure 400 {object} api.Problem "Invalid input"
+// @Failure 422 {object} api.Problem "Validation failed"
+// @Failure 503 {object} api.Problem "Database unavailable"
+// @Failure 500 {object} api.Problem "Internal server error"
+// @Router /rates [post]
+func (h *HandlerRate) LoadRates(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	format := domain.RatesJSON
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "text/csv" {
+		format = domain.RatesCSV
+	}
+
+	loaded, err := h.service.LoadRates(r.Context(), r.Body, format)
+	if err != nil {
+		api.WriteServiceError(w, r, err)
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, domain.RatesResponse{Loaded: loaded})
+}
+
+// GetRates godoc
+// @Summary Get exchange rates
+// @Description Get exchange rates of a currency ordered by date
+// @Tags rates
+// @Accept  json
+// @Produce  json
+// @Param currency query string true "ISO-4217 currency code"
+// @Success 200 {array} domain.RateResponse "Exchange rates"
+// @Failure 400 {object} api.Problem "Invalid currency"
+// @Failure 503 {object} api.Problem "Database unavailable"
+// @Failure 500 {object} api.Problem "Internal server error"
+// @Router /rates [get]
+func (h *HandlerRate) GetRates(w http.ResponseWriter, r *http.Request) {
+	currency := r.URL.Query().Get("currency")
+	if !domain.ValidCurrency(currency) {
+		api.WriteFieldError(w, r, "currency", "must be ISO-4217 currency code")
+		return
+	}
+
+	rates, err := h.service.GetRates(r.Context(), currency)
+	if err != nil {
+		api.WriteServiceError(w, r, err)
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, domain.ConvertRatesToResponse(rates))
+}
@@ -0,0 +1,17 @@
+package rate
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/delivery/api"
+)
+
+func NewRouter(rates *HandlerRate) chi.Router {
+	r := chi.NewRouter()
+	r.NotFound(api.NotFound)
+	r.MethodNotAllowed(api.MethodNotAllowed)
+
+	r.Get("/", rates.GetRates)
+	r.Post("/", rates.LoadRates)
+
+	return r
+}
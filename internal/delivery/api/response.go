@@ -0,0 +1,192 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/domain"
+)
+
+const problemContentType = "application/problem+json"
+
+// Коды ошибок в теле ответа
+const (
+	CodeBadRequest       = "bad_request"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeConflict         = "conflict"
+	CodePrecondition     = "precondition_failed"
+	CodeValidation       = "validation_failed"
+	CodeUnavailable      = "unavailable"
+	CodeInternal         = "internal_error"
+)
+
+// Problem represents RFC 7807 problem details
+type Problem struct {
+	Type     string         `json:"type" example:"/problems/not-found"`
+	Title    string         `json:"title" example:"Not Found"`
+	Status   int            `json:"status" example:"404"`
+	Detail   string         `json:"detail,omitempty" example:"subscription not found"`
+	Instance string         `json:"instance,omitempty" example:"/api/subs/update/550e8400-e29b-41d4-a716-446655440000"`
+	Code     string         `json:"code" example:"not_found"`
+	Errors   []ProblemField `json:"errors,omitempty"`
+	// ConflictingID is the ID of subscription the request conflicts with
+	ConflictingID *uuid.UUID `json:"conflicting_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
+}
+
+// ProblemField represents validation error of a single field
+type ProblemField struct {
+	Field   string `json:"field" example:"price"`
+	Message string `json:"message" example:"must be positive"`
+}
+
+// WriteJSON пишет тело ответа в формате JSON
+func WriteJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("failed to encode response: %v", err)
+	}
+}
+
+// WriteProblem пишет ошибку в формате application/problem+json
+func WriteProblem(w http.ResponseWriter, problem *Problem) {
+	if problem.Type == "" {
+		problem.Type = "/problems/" + strings.ReplaceAll(problem.Code, "_", "-")
+	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(problem.Status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		log.Printf("failed to encode problem: %v", err)
+	}
+}
+
+// WriteError пишет ошибку с указанным статусом и кодом
+func WriteError(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	WriteProblem(w, &Problem{
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		Code:     code,
+	})
+}
+
+// WriteFieldError пишет ошибку 400 для одного некорректного поля запроса
+func WriteFieldError(w http.ResponseWriter, r *http.Request, field, detail string) {
+	WriteProblem(w, &Problem{
+		Status:   http.StatusBadRequest,
+		Detail:   fmt.Sprintf("invalid %s", field),
+		Instance: r.URL.Path,
+		Code:     CodeBadRequest,
+		Errors: []ProblemField{{
+			Field:   field,
+			Message: detail,
+		}},
+	})
+}
+
+// WriteInvalidParams пишет ошибку 400 для некорректных параметров запроса
+func WriteInvalidParams(w http.ResponseWriter, r *http.Request, err *domain.ValidationError) {
+	problem := &Problem{
+		Status:   http.StatusBadRequest,
+		Detail:   "request contains invalid parameters",
+		Instance: r.URL.Path,
+		Code:     CodeBadRequest,
+	}
+
+	for _, field := range err.Fields {
+		problem.Errors = append(problem.Errors, ProblemField{
+			Field:   field.Field,
+			Message: field.Message,
+		})
+	}
+
+	WriteProblem(w, problem)
+}
+
+// WriteBodyError пишет ошибку 400 для тела запроса, которое не удалось разобрать
+func WriteBodyError(w http.ResponseWriter, r *http.Request, err error) {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		WriteFieldError(w, r, typeErr.Field, fmt.Sprintf("must be %s", typeErr.Type))
+		return
+	}
+
+	WriteError(w, r, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+}
+
+// WriteServiceError переводит ошибку сервиса в problem details и пишет её клиенту
+func WriteServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	status, code := statusFromError(err)
+
+	problem := &Problem{
+		Status:   status,
+		Detail:   http.StatusText(status),
+		Instance: r.URL.Path,
+		Code:     code,
+	}
+
+	var domainErr *domain.Error
+	if status != http.StatusInternalServerError && errors.As(err, &domainErr) {
+		problem.Detail = domainErr.Message
+	}
+
+	var overlapErr *domain.OverlapError
+	if errors.As(err, &overlapErr) {
+		problem.Detail = overlapErr.Error()
+		problem.ConflictingID = &overlapErr.ConflictingID
+	}
+
+	var validationErr *domain.ValidationError
+	if errors.As(err, &validationErr) {
+		problem.Detail = "request contains invalid fields"
+		for _, field := range validationErr.Fields {
+			problem.Errors = append(problem.Errors, ProblemField{
+				Field:   field.Field,
+				Message: field.Message,
+			})
+		}
+	}
+
+	if status >= http.StatusInternalServerError {
+		log.Printf("%s %s: %s: %v", r.Method, r.URL.Path, code, err)
+	}
+
+	WriteProblem(w, problem)
+}
+
+// NotFound отвечает problem details на запрос к несуществующему маршруту
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	WriteError(w, r, http.StatusNotFound, CodeNotFound, "route not found")
+}
+
+// MethodNotAllowed отвечает problem details на запрос с неподдерживаемым методом
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	WriteError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "method not allowed")
+}
+
+func statusFromError(err error) (int, string) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound, CodeNotFound
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict, CodeConflict
+	case errors.Is(err, domain.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed, CodePrecondition
+	case errors.Is(err, domain.ErrValidation):
+		return http.StatusUnprocessableEntity, CodeValidation
+	case errors.Is(err, domain.ErrUnavailable):
+		return http.StatusServiceUnavailable, CodeUnavailable
+	default:
+		return http.StatusInternalServerError, CodeInternal
+	}
+}
@@ -0,0 +1,160 @@
+package stats
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/delivery/api"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/domain"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/utils"
+)
+
+type StatsService interface {
+	GetServiceStats(ctx context.Context, filter domain.StatsFilter) (*domain.ServiceStats, error)
+	GetStatsSummary(ctx context.Context, filter domain.StatsFilter) (*domain.StatsSummary, error)
+}
+
+type HandlerStats struct {
+	service StatsService
+}
+
+func New(service StatsService) *HandlerStats {
+	return &HandlerStats{
+		service: service,
+	}
+}
+
+// GetServiceStats godoc
+// @Summary Get service statistics
+// @Description Get statistics of subscriptions to a catalog service over the period and per month of the period:
+// @Description active subscribers and subscriptions, new and ended subscriptions, average and median price
+// @Description and average tenure in months. service is the ID of the catalog service or its name or alias,
+// @Description ignoring case and extra spaces.
+// @Description A subscription is active in a month if it is active on at least one day of it and is not paused
+// @Description for the whole month. Prices are trial, promo or regular prices in effect on the last active day of the month
+// @Description per month of billing, converted to target_currency at the exchange rate effective on that day.
+// @Description Tenure is counted from start_date to the last active day. Returns 422 if an exchange rate is missing.
+// @Tags stats
+// @Accept  json
+// @Produce  json
+// @Param service path string true "Service ID, name or alias"
+// @Param start_period query string true "Start of the period" example(2025-01)
+// @Param end_period query string true "End of the period" example(2025-12)
+// @Param target_currency query string false "ISO-4217 currency code of prices, RUB by default"
+// @Param date_format query string false "Format of months in response, the format start_period was sent in by default" Enums(YYYY-MM-DD, YYYY-MM, MM-YYYY)
+// @Param X-Date-Format header string false "Format of months in response if date_format is not set" Enums(YYYY-MM-DD, YYYY-MM, MM-YYYY)
+// @Success 200 {object} domain.ServiceStatsResponse "Service statistics"
+// @Failure 400 {object} api.Problem "Invalid input"
+// @Failure 404 {object} api.Problem "Service not found"
+// @Failure 422 {object} api.Problem "Validation failed"
+// @Failure 503 {object} api.Problem "Database unavailable"
+// @Failure 500 {object} api.Problem "Internal server error"
+// @Router /stats/services/{service} [get]
+func (h *HandlerStats) GetServiceStats(w http.ResponseWriter, r *http.Request) {
+	filter, dateFormat, ok := parseStatsFilter(w, r)
+	if !ok {
+		return
+	}
+
+	service := strings.TrimSpace(chi.URLParam(r, "service"))
+	if service == "" {
+		api.WriteFieldError(w, r, "service", "must not be empty")
+		return
+	}
+	if serviceID, err := uuid.Parse(service); err == nil {
+		filter.ServiceID = &serviceID
+	} else {
+		filter.ServiceName = &service
+	}
+
+	stats, err := h.service.GetServiceStats(r.Context(), filter)
+	if err != nil {
+		api.WriteServiceError(w, r, err)
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, domain.ConvertServiceStatsToResponse(stats, dateFormat))
+}
+
+// GetStatsSummary godoc
+// @Summary Get statistics of all services
+// @Description Get statistics of subscriptions to all services over the period and per service, services are ordered
+// @Description by active subscribers. Statistics are computed as for a single service with the whole period instead of a month.
+// @Description Returns 422 if an exchange rate is missing.
+// @Tags stats
+// @Accept  json
+// @Produce  json
+// @Param start_period query string true "Start of the period" example(2025-01)
+// @Param end_period query string true "End of the period" example(2025-12)
+// @Param target_currency query string false "ISO-4217 currency code of prices, RUB by default"
+// @Success 200 {object} domain.StatsSummaryResponse "Statistics of all services"
+// @Failure 400 {object} api.Problem "Invalid input"
+// @Failure 422 {object} api.Problem "Validation failed"
+// @Failure 503 {object} api.Problem "Database unavailable"
+// @Failure 500 {object} api.Problem "Internal server error"
+// @Router /stats/services [get]
+func (h *HandlerStats) GetStatsSummary(w http.ResponseWriter, r *http.Request) {
+	filter, _, ok := parseStatsFilter(w, r)
+	if !ok {
+		return
+	}
+
+	summary, err := h.service.GetStatsSummary(r.Context(), filter)
+	if err != nil {
+		api.WriteServiceError(w, r, err)
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, domain.ConvertStatsSummaryToResponse(summary))
+}
+
+// parseStatsFilter разбирает период, целевую валюту и формат месяцев ответа из query string,
+// при ошибке пишет ответ и возвращает false
+func parseStatsFilter(w http.ResponseWriter, r *http.Request) (domain.StatsFilter, utils.DateFormat, bool) {
+	var filter domain.StatsFilter
+	query := r.URL.Query()
+
+	// Месяц без дня означает весь месяц
+	startDate, _, dateFormat, err := utils.ParsePeriod(query.Get("start_period"))
+	if err != nil {
+		api.WriteFieldError(w, r, "start_period", err.Error())
+		return filter, "", false
+	}
+
+	_, endDate, _, err := utils.ParsePeriod(query.Get("end_period"))
+	if err != nil {
+		api.WriteFieldError(w, r, "end_period", err.Error())
+		return filter, "", false
+	}
+
+	if endDate.Before(startDate) {
+		api.WriteFieldError(w, r, "end_period", "invalid date range: end period is before start period")
+		return filter, "", false
+	}
+
+	format, err := api.ParseDateFormat(r)
+	if err != nil {
+		api.WriteFieldError(w, r, "date_format", err.Error())
+		return filter, "", false
+	}
+	if format != "" {
+		dateFormat = format
+	}
+
+	filter.TargetCurrency = strings.ToUpper(strings.TrimSpace(query.Get("target_currency")))
+	if filter.TargetCurrency == "" {
+		filter.TargetCurrency = domain.BaseCurrency
+	}
+	if !domain.ValidCurrency(filter.TargetCurrency) {
+		api.WriteFieldError(w, r, "target_currency", "must be ISO-4217 currency code")
+		return filter, "", false
+	}
+
+	filter.StartPeriod = utils.ToDateString(startDate)
+	filter.EndPeriod = utils.ToDateString(endDate)
+
+	return filter, dateFormat, true
+}
@@ -0,0 +1,17 @@
+package stats
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/delivery/api"
+)
+
+func NewRouter(stats *HandlerStats) chi.Router {
+	r := chi.NewRouter()
+	r.NotFound(api.NotFound)
+	r.MethodNotAllowed(api.MethodNotAllowed)
+
+	r.Get("/services", stats.GetStatsSummary)
+	r.Get("/services/{service}", stats.GetServiceStats)
+
+	return r
+}
@@ -0,0 +1,92 @@
+package sub
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maYkiss56/subscription-aggregation-service/internal/domain"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/utils"
+)
+
+var errInvalidETag = errors.New("must be a list of quoted ETags or *")
+
+// etag формирует ETag представления подписки: ответ зависит не только от версии подписки, но и от
+// формата дат и от дня, на который вычислены статус и текущая цена. If-Match сверяет только версию
+func etag(version int, format utils.DateFormat, today time.Time) string {
+	return fmt.Sprintf(`"%d.%s.%s"`, version, format, today.Format("20060102"))
+}
+
+// setETag устанавливает ETag ответа с подпиской в формате дат format, по умолчанию формате подписки,
+// и возвращает его. Формат может быть задан заголовком X-Date-Format, поэтому ответ зависит от него
+func setETag(w http.ResponseWriter, sub *domain.Sub, format utils.DateFormat, today time.Time) string {
+	if format == "" {
+		format = sub.DateFormat
+	}
+
+	tag := etag(sub.Version, format, today)
+	w.Header().Set("ETag", tag)
+	w.Header().Add("Vary", "X-Date-Format")
+
+	return tag
+}
+
+// noVersion не совпадает ни с одной версией подписки (версии начинаются с 1): так заголовок
+// из одних несовпадающих ETag остаётся предусловием, которое не выполняется
+const noVersion = 0
+
+// parseIfMatch возвращает версии из заголовка If-Match,
+// nil означает отсутствие предусловия (заголовка нет или указан *)
+func parseIfMatch(r *http.Request) ([]int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	var versions []int
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		// Для If-Match используется строгое сравнение (RFC 9110), слабые ETag не совпадают никогда
+		if strings.HasPrefix(tag, "W/") {
+			versions = append(versions, noVersion)
+			continue
+		}
+		if !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) || len(tag) < 2 {
+			return nil, errInvalidETag
+		}
+
+		// Версия идёт в ETag первой, остальные части ETag не относятся к состоянию подписки.
+		// Чужой ETag без версии просто не совпадает
+		value, _, _ := strings.Cut(strings.Trim(tag, `"`), ".")
+		version, err := strconv.Atoi(value)
+		if err != nil || version <= 0 {
+			version = noVersion
+		}
+		versions = append(versions, version)
+	}
+
+	return versions, nil
+}
+
+// matchesIfNoneMatch сообщает, совпадает ли ETag с заголовком If-None-Match (слабое сравнение)
+func matchesIfNoneMatch(r *http.Request, tag string) bool {
+	header := strings.TrimSpace(r.Header.Get("If-None-Match"))
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == tag {
+			return true
+		}
+	}
+
+	return false
+}
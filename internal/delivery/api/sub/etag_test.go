@@ -0,0 +1,78 @@
+package sub
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/maYkiss56/subscription-aggregation-service/internal/utils"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    []int
+		wantErr bool
+	}{
+		{name: "no header"},
+		{name: "any", header: "*"},
+		{name: "version", header: `"3"`, want: []int{3}},
+		{name: "representation tag", header: `"3.YYYY-MM.20250117"`, want: []int{3}},
+		{name: "list", header: `"3", "4.MM-YYYY.20250117"`, want: []int{3, 4}},
+		{name: "weak tag never matches", header: `W/"3"`, want: []int{noVersion}},
+		{name: "weak and strong", header: `W/"3", "4"`, want: []int{noVersion, 4}},
+		{name: "foreign tag never matches", header: `"abc"`, want: []int{noVersion}},
+		{name: "unquoted", header: "3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("PATCH", "/", nil)
+			if tt.header != "" {
+				r.Header.Set("If-Match", tt.header)
+			}
+
+			got, err := parseIfMatch(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseIfMatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseIfMatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesIfNoneMatch(t *testing.T) {
+	today := time.Date(2025, 1, 17, 0, 0, 0, 0, time.UTC)
+	tag := etag(3, utils.DateFormatMonthYear, today)
+
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{name: "no header"},
+		{name: "any", header: "*", want: true},
+		{name: "same", header: tag, want: true},
+		{name: "weak", header: "W/" + tag, want: true},
+		{name: "other format", header: etag(3, utils.DateFormatDate, today)},
+		{name: "other day", header: etag(3, utils.DateFormatMonthYear, today.AddDate(0, 0, 1))},
+		{name: "other version", header: etag(2, utils.DateFormatMonthYear, today)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				r.Header.Set("If-None-Match", tt.header)
+			}
+
+			if got := matchesIfNoneMatch(r, tag); got != tt.want {
+				t.Errorf("matchesIfNoneMatch(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
@@ -3,19 +3,20 @@ package sub
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
+	"io"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/delivery/api"
 	"github.com/maYkiss56/subscription-aggregation-service/internal/domain"
 	"github.com/maYkiss56/subscription-aggregation-service/internal/utils"
 )
 
 const (
-	ErrInvalidBody      = "invalid request body"
-	ErrInvalidSubData   = "invalid subscription data"
-	ErrInternalServer   = "internal server error"
 	ErrInvalidUserID    = "invalid user id"
 	ErrInvalidSubID     = "invalid subscription id"
 	ErrInvalidDateRange = "invalid date range"
@@ -23,11 +24,16 @@ const (
 
 type SubService interface {
 	CreateSub(ctx context.Context, sub *domain.Sub) (id uuid.UUID, err error)
-	GetAllSubs(ctx context.Context) ([]*domain.Sub, error)
-	GetSubByUserID(ctx context.Context, userUID uuid.UUID) ([]*domain.Sub, error)
-	UpdateSub(ctx context.Context, id uuid.UUID, req *domain.UpdateSubRequest) (*domain.Sub, error)
-	DeleteSub(ctx context.Context, id uuid.UUID) error
-	CalculateTotalCost(ctx context.Context, filter domain.TotalCostFilter) (int, error)
+	GetAllSubs(ctx context.Context, params domain.ListSubsParams) (*domain.SubPage, error)
+	GetSubByUserID(ctx context.Context, userUID uuid.UUID, params domain.ListSubsParams) (*domain.SubPage, error)
+	GetSubByID(ctx context.Context, id uuid.UUID, includeDeleted bool) (*domain.Sub, error)
+	UpdateSub(ctx context.Context, id uuid.UUID, req *domain.UpdateSubRequest, versions []int) (*domain.Sub, error)
+	PauseSub(ctx context.Context, id uuid.UUID, from time.Time, to *time.Time, versions []int) (*domain.Sub, error)
+	ResumeSub(ctx context.Context, id uuid.UUID, at time.Time, versions []int) (*domain.Sub, error)
+	DeleteSub(ctx context.Context, id uuid.UUID, versions []int) error
+	RestoreSub(ctx context.Context, id uuid.UUID) (*domain.Sub, error)
+	PurgeDeletedSubs(ctx context.Context, retention time.Duration) (int64, error)
+	CalculateTotalCost(ctx context.Context, filter domain.TotalCostFilter) (*domain.TotalCost, error)
 }
 
 type HandlerSub struct {
@@ -42,258 +48,817 @@ func New(service SubService) *HandlerSub {
 
 // CreateSub godoc
 // @Summary Create a new subscription
-// @Description Create a new subscription with the input payload
+// @Description Create a new subscription with the input payload.
+// @Description Dates are YYYY-MM-DD, YYYY-MM or MM-YYYY, a month means its first day for start_date and billing_anchor
+// @Description and its last day for end_date. Responses use the format start_date was sent in.
+// @Description Omit end_date to create an open-ended subscription.
+// @Description service_name is matched to the services catalog by name or alias ignoring case and extra spaces,
+// @Description unknown names are added to the catalog. Set service_id to refer to a catalog service directly.
+// @Description Subscriptions get the category of the catalog service unless category is set.
+// @Description Trial and promo phases are charged from start_date one after another before the regular price.
+// @Description Returns 409 with conflicting_id if the subscription overlaps with another subscription of the same user
+// @Description and service, set allow_overlap to create it anyway.
+// @Description Subscriptions are billed monthly from start_date unless billing_period and billing_anchor are set.
 // @Tags subscriptions
 // @Accept  json
 // @Produce  json
 // @Param input body domain.CreateSubRequest true "Create subscription"
+// @Param allow_overlap query bool false "Allow overlapping with subscriptions of the same user and service"
 // @Success 201 {object} map[string]interface{} "Subscription created"
-// @Failure 400 {string} string "Invalid input"
-// @Failure 500 {string} string "Internal server error"
-// @Router /create [post]
+// @Failure 400 {object} api.Problem "Invalid input"
+// @Failure 409 {object} api.Problem "Subscription overlaps with subscription conflicting_id of the same user and service"
+// @Failure 422 {object} api.Problem "Validation failed"
+// @Failure 503 {object} api.Problem "Database unavailable"
+// @Failure 500 {object} api.Problem "Internal server error"
+// @Router /subs/create [post]
 func (h *HandlerSub) CreateSub(w http.ResponseWriter, r *http.Request) {
+	allowOverlap, err := parseBoolParam(r, "allow_overlap")
+	if err != nil {
+		api.WriteFieldError(w, r, "allow_overlap", err.Error())
+		return
+	}
+
 	var req domain.CreateSubRequest
 	defer r.Body.Close()
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, fmt.Sprintf("%s: %v", ErrInvalidBody, err), http.StatusBadRequest)
+		api.WriteBodyError(w, r, err)
 		return
 	}
 
-	// Парсим start_date как первый день месяца
-	startDate, err := utils.ParseMonthYear(req.StartDate)
+	// Парсим start_date, месяц без дня означает его первый день
+	startDate, _, dateFormat, err := utils.ParsePeriod(req.StartDate)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("invalid start date: %v", err), http.StatusBadRequest)
+		api.WriteFieldError(w, r, "start_date", err.Error())
 		return
 	}
 
-	// Парсим end_date как последний день месяца
-	endDate, err := utils.ParseMonthYearToEndOfMonth(req.EndDate)
+	// Парсим end_date, месяц без дня означает его последний день, без end_date подписка бессрочная
+	var endDate *time.Time
+	if req.EndDate != nil {
+		_, parsed, _, err := utils.ParsePeriod(*req.EndDate)
+		if err != nil {
+			api.WriteFieldError(w, r, "end_date", err.Error())
+			return
+		}
+		endDate = &parsed
+	}
+
+	// Парсим billing_anchor, месяц без дня означает его первый день, без него списания идут от start_date
+	var billingAnchor *time.Time
+	if req.BillingAnchor != nil {
+		parsed, _, _, err := utils.ParsePeriod(*req.BillingAnchor)
+		if err != nil {
+			api.WriteFieldError(w, r, "billing_anchor", err.Error())
+			return
+		}
+		billingAnchor = &parsed
+	}
+
+	// Цены передаются в основных единицах валюты и хранятся в минимальных
+	currency := domain.NormalizeCurrency(req.Currency)
+	price, err := domain.ParseMoney(req.Price.String(), currency)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("invalid end date: %v", err), http.StatusBadRequest)
+		api.WriteFieldError(w, r, "price", err.Error())
+		return
+	}
+
+	phases, verr := domain.NewPricePhases(req.Phases, currency)
+	if verr != nil {
+		api.WriteInvalidParams(w, r, verr)
 		return
 	}
 
-	newSub, err := domain.New(req.ServiceName, req.Price, req.UserID, startDate, endDate)
+	billing := domain.NewBilling(req.BillingPeriod, req.BillingInterval, billingAnchor, startDate)
+
+	var serviceID uuid.UUID
+	if req.ServiceID != nil {
+		serviceID = *req.ServiceID
+	}
+
+	newSub, err := domain.New(req.ServiceName, serviceID, price, phases, req.UserID, startDate, endDate, billing, dateFormat)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("%s: %v", ErrInvalidSubData, err), http.StatusBadRequest)
+		api.WriteServiceError(w, r, err)
 		return
 	}
 
+	if err := newSub.SetLabels(req.Category, req.Tags); err != nil {
+		api.WriteServiceError(w, r, err)
+		return
+	}
+
+	newSub.AllowOverlap = allowOverlap
+
 	id, err := h.service.CreateSub(r.Context(), newSub)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("%s: %v", ErrInternalServer, err), http.StatusInternalServerError)
+		api.WriteServiceError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+	api.WriteJSON(w, http.StatusCreated, map[string]interface{}{
 		"message": "subscription created successfully",
 		"id":      id,
-	}); err != nil {
-		http.Error(w, fmt.Sprintf("%s: %v", ErrInternalServer, err), http.StatusInternalServerError)
-	}
+	})
 }
 
 // GetAllSubs godoc
 // @Summary Get all subscriptions
-// @Description Get list of all subscriptions
+// @Description Get a page of subscriptions with keyset pagination, sorting and filtering
 // @Tags subscriptions
 // @Accept  json
 // @Produce  json
-// @Success 200 {array} domain.Sub "List of subscriptions"
-// @Failure 500 {string} string "Internal server error"
-// @Router / [get]
+// @Param limit query int false "Page size (1-500, default 50)"
+// @Param cursor query string false "Cursor from next_cursor of the previous page"
+// @Param sort query string false "Sort field, price is the regular price in effect today" Enums(start_date, price, service_name)
+// @Param order query string false "Sort direction" Enums(asc, desc)
+// @Param service_name query string false "Filter by name or alias of catalog service"
+// @Param service_id query string false "Filter by catalog service ID"
+// @Param category query string false "Filter by category, case is ignored"
+// @Param tags query string false "Comma separated tags the subscription must all have"
+// @Param min_price query number false "Minimal regular price in effect today in major units of subscription currency"
+// @Param max_price query number false "Maximal regular price in effect today in major units of subscription currency"
+// @Param active_at query string false "Date (YYYY-MM-DD) or month (YYYY-MM, MM-YYYY) the subscription is active at"
+// @Param ended query bool false "Filter by whether subscription has ended"
+// @Param status query string false "Comma separated statuses at status_at: scheduled, active, ending_soon, ended, paused"
+// @Param status_at query string false "Reference date (YYYY-MM-DD) or month (YYYY-MM, MM-YYYY, its first day) for status, today by default"
+// @Param include_deleted query bool false "Include deleted subscriptions (for administrators)"
+// @Param date_format query string false "Format of dates in response, the format dates were sent in by default" Enums(YYYY-MM-DD, YYYY-MM, MM-YYYY)
+// @Param X-Date-Format header string false "Format of dates in response if date_format is not set" Enums(YYYY-MM-DD, YYYY-MM, MM-YYYY)
+// @Success 200 {object} domain.SubListResponse "Page of subscriptions"
+// @Failure 400 {object} api.Problem "Invalid query parameters"
+// @Failure 422 {object} api.Problem "Validation failed"
+// @Failure 503 {object} api.Problem "Database unavailable"
+// @Failure 500 {object} api.Problem "Internal server error"
+// @Router /subs [get]
 func (h *HandlerSub) GetAllSubs(w http.ResponseWriter, r *http.Request) {
-	subs, err := h.service.GetAllSubs(r.Context())
-	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to get subscriptions: %v", err), http.StatusInternalServerError)
+	params, verr := parseListParams(r)
+	if verr != nil {
+		api.WriteInvalidParams(w, r, verr)
 		return
 	}
 
-	response := domain.ConvertSubsToResponse(subs)
+	dateFormat, err := api.ParseDateFormat(r)
+	if err != nil {
+		api.WriteFieldError(w, r, "date_format", err.Error())
+		return
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, fmt.Sprintf("%s: %v", ErrInternalServer, err), http.StatusInternalServerError)
+	page, err := h.service.GetAllSubs(r.Context(), params)
+	if err != nil {
+		api.WriteServiceError(w, r, err)
+		return
 	}
+
+	response := domain.ConvertSubPageToResponse(page, dateFormat, params.StatusAt)
+
+	api.WriteJSON(w, http.StatusOK, response)
 }
 
 // GetSubByUserID godoc
 // @Summary Get subscriptions by user ID
-// @Description Get list of subscriptions for specific user
+// @Description Get a page of subscriptions for specific user with keyset pagination, sorting and filtering
 // @Tags subscriptions
 // @Accept  json
 // @Produce  json
 // @Param user_id path string true "User ID"
-// @Success 200 {array} domain.Sub "List of user subscriptions"
-// @Failure 400 {string} string "Invalid user ID"
-// @Failure 500 {string} string "Internal server error"
-// @Router /{user_id} [get]
+// @Param limit query int false "Page size (1-500, default 50)"
+// @Param cursor query string false "Cursor from next_cursor of the previous page"
+// @Param sort query string false "Sort field, price is the regular price in effect today" Enums(start_date, price, service_name)
+// @Param order query string false "Sort direction" Enums(asc, desc)
+// @Param service_name query string false "Filter by name or alias of catalog service"
+// @Param service_id query string false "Filter by catalog service ID"
+// @Param category query string false "Filter by category, case is ignored"
+// @Param tags query string false "Comma separated tags the subscription must all have"
+// @Param min_price query number false "Minimal regular price in effect today in major units of subscription currency"
+// @Param max_price query number false "Maximal regular price in effect today in major units of subscription currency"
+// @Param active_at query string false "Date (YYYY-MM-DD) or month (YYYY-MM, MM-YYYY) the subscription is active at"
+// @Param ended query bool false "Filter by whether subscription has ended"
+// @Param status query string false "Comma separated statuses at status_at: scheduled, active, ending_soon, ended, paused"
+// @Param status_at query string false "Reference date (YYYY-MM-DD) or month (YYYY-MM, MM-YYYY, its first day) for status, today by default"
+// @Param include_deleted query bool false "Include deleted subscriptions (for administrators)"
+// @Param date_format query string false "Format of dates in response, the format dates were sent in by default" Enums(YYYY-MM-DD, YYYY-MM, MM-YYYY)
+// @Param X-Date-Format header string false "Format of dates in response if date_format is not set" Enums(YYYY-MM-DD, YYYY-MM, MM-YYYY)
+// @Success 200 {object} domain.SubListResponse "Page of user subscriptions"
+// @Failure 400 {object} api.Problem "Invalid user ID or query parameters"
+// @Failure 422 {object} api.Problem "Validation failed"
+// @Failure 503 {object} api.Problem "Database unavailable"
+// @Failure 500 {object} api.Problem "Internal server error"
+// @Router /subs/{user_id} [get]
 func (h *HandlerSub) GetSubByUserID(w http.ResponseWriter, r *http.Request) {
 	userIDStr := chi.URLParam(r, "user_id")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("%s: %v", ErrInvalidUserID, err), http.StatusBadRequest)
+		api.WriteFieldError(w, r, "user_id", ErrInvalidUserID)
 		return
 	}
 
-	subs, err := h.service.GetSubByUserID(r.Context(), userID)
+	params, verr := parseListParams(r)
+	if verr != nil {
+		api.WriteInvalidParams(w, r, verr)
+		return
+	}
+
+	dateFormat, err := api.ParseDateFormat(r)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to get user subscriptions: %v", err), http.StatusInternalServerError)
+		api.WriteFieldError(w, r, "date_format", err.Error())
 		return
 	}
 
-	response := domain.ConvertSubsToResponse(subs)
+	page, err := h.service.GetSubByUserID(r.Context(), userID, params)
+	if err != nil {
+		api.WriteServiceError(w, r, err)
+		return
+	}
+
+	response := domain.ConvertSubPageToResponse(page, dateFormat, params.StatusAt)
+
+	api.WriteJSON(w, http.StatusOK, response)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, fmt.Sprintf("%s: %v", ErrInternalServer, err), http.StatusInternalServerError)
+// GetSubByID godoc
+// @Summary Get subscription by ID
+// @Description Get single subscription by its ID. ETag header identifies the subscription version, the format
+// @Description of dates and the day status and current_price are computed for. Send it in If-None-Match to get 304
+// @Description when none of them has changed. ETag of any subscription response can be sent in If-Match to update it.
+// @Tags subscriptions
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Subscription ID"
+// @Param If-None-Match header string false "ETag of the cached subscription"
+// @Param include_deleted query bool false "Return the subscription even if it is deleted (for administrators)"
+// @Param date_format query string false "Format of dates in response, the format dates were sent in by default" Enums(YYYY-MM-DD, YYYY-MM, MM-YYYY)
+// @Param X-Date-Format header string false "Format of dates in response if date_format is not set" Enums(YYYY-MM-DD, YYYY-MM, MM-YYYY)
+// @Success 200 {object} domain.SubResponse "Subscription"
+// @Success 304 "Not modified"
+// @Failure 400 {object} api.Problem "Invalid subscription ID"
+// @Failure 404 {object} api.Problem "Subscription not found"
+// @Failure 503 {object} api.Problem "Database unavailable"
+// @Failure 500 {object} api.Problem "Internal server error"
+// @Router /subs/id/{id} [get]
+func (h *HandlerSub) GetSubByID(w http.ResponseWriter, r *http.Request) {
+	subIDStr := chi.URLParam(r, "id")
+	subID, err := uuid.Parse(subIDStr)
+	if err != nil {
+		api.WriteFieldError(w, r, "id", ErrInvalidSubID)
+		return
 	}
+
+	includeDeleted, err := parseBoolParam(r, "include_deleted")
+	if err != nil {
+		api.WriteFieldError(w, r, "include_deleted", err.Error())
+		return
+	}
+
+	dateFormat, err := api.ParseDateFormat(r)
+	if err != nil {
+		api.WriteFieldError(w, r, "date_format", err.Error())
+		return
+	}
+
+	sub, err := h.service.GetSubByID(r.Context(), subID, includeDeleted)
+	if err != nil {
+		api.WriteServiceError(w, r, err)
+		return
+	}
+
+	today := domain.Today()
+	tag := setETag(w, sub, dateFormat, today)
+	if matchesIfNoneMatch(r, tag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	response := domain.ConvertSubToResponse(sub, dateFormat, today)
+
+	api.WriteJSON(w, http.StatusOK, response)
+}
+
+// GetPriceTimeline godoc
+// @Summary Get subscription price timeline
+// @Description Get periods of trial and promo phases and regular prices of subscription in chronological order
+// @Tags subscriptions
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Subscription ID"
+// @Param date_format query string false "Format of dates in response, the format dates were sent in by default" Enums(YYYY-MM-DD, YYYY-MM, MM-YYYY)
+// @Param X-Date-Format header string false "Format of dates in response if date_format is not set" Enums(YYYY-MM-DD, YYYY-MM, MM-YYYY)
+// @Success 200 {array} domain.PricePeriodResponse "Price timeline"
+// @Failure 400 {object} api.Problem "Invalid subscription ID"
+// @Failure 404 {object} api.Problem "Subscription not found"
+// @Failure 503 {object} api.Problem "Database unavailable"
+// @Failure 500 {object} api.Problem "Internal server error"
+// @Router /subs/prices/{id} [get]
+func (h *HandlerSub) GetPriceTimeline(w http.ResponseWriter, r *http.Request) {
+	subIDStr := chi.URLParam(r, "id")
+	subID, err := uuid.Parse(subIDStr)
+	if err != nil {
+		api.WriteFieldError(w, r, "id", ErrInvalidSubID)
+		return
+	}
+
+	dateFormat, err := api.ParseDateFormat(r)
+	if err != nil {
+		api.WriteFieldError(w, r, "date_format", err.Error())
+		return
+	}
+
+	sub, err := h.service.GetSubByID(r.Context(), subID, false)
+	if err != nil {
+		api.WriteServiceError(w, r, err)
+		return
+	}
+
+	if dateFormat == "" {
+		dateFormat = sub.DateFormat
+	}
+
+	response := domain.ConvertPriceTimelineToResponse(sub.PriceTimeline(), dateFormat)
+
+	api.WriteJSON(w, http.StatusOK, response)
 }
 
 // UpdateSub godoc
 // @Summary Update subscription
-// @Description Update existing subscription
+// @Description Update existing subscription using JSON Merge Patch (RFC 7396) semantics:
+// @Description omitted fields stay as they are, explicit null clears end_date.
+// @Description A new price does not change history, it is added to the price history effective from price_effective_from
+// @Description (today or start_date if it is later by default). currency can only be changed before start_date.
+// @Description A new service_name is matched to the services catalog again, service_id without service_name
+// @Description also renames the subscription to the catalog service name.
 // @Tags subscriptions
-// @Accept  json
+// @Accept  json,application/merge-patch+json
 // @Produce  json
 // @Param id path string true "Subscription ID"
+// @Param If-Match header string false "ETag of the subscription the update is based on"
 // @Param input body domain.UpdateSubRequest true "Update data"
-// @Success 200 {object} domain.Sub "Updated subscription"
-// @Failure 400 {string} string "Invalid input"
-// @Failure 404 {string} string "Subscription not found"
-// @Failure 500 {string} string "Internal server error"
-// @Router /update/{id} [patch]
+// @Param allow_overlap query bool false "Allow (true) or forbid (false) overlapping with subscriptions of the same user and service from now on, unchanged if omitted"
+// @Param date_format query string false "Format of dates in response, the format dates were sent in by default" Enums(YYYY-MM-DD, YYYY-MM, MM-YYYY)
+// @Param X-Date-Format header string false "Format of dates in response if date_format is not set" Enums(YYYY-MM-DD, YYYY-MM, MM-YYYY)
+// @Success 200 {object} domain.SubResponse "Updated subscription"
+// @Failure 400 {object} api.Problem "Invalid input"
+// @Failure 404 {object} api.Problem "Subscription not found"
+// @Failure 409 {object} api.Problem "Subscription overlaps with subscription conflicting_id of the same user and service"
+// @Failure 412 {object} api.Problem "Subscription version does not match If-Match"
+// @Failure 422 {object} api.Problem "Validation failed"
+// @Failure 503 {object} api.Problem "Database unavailable"
+// @Failure 500 {object} api.Problem "Internal server error"
+// @Router /subs/update/{id} [patch]
 func (h *HandlerSub) UpdateSub(w http.ResponseWriter, r *http.Request) {
 	subIDStr := chi.URLParam(r, "id")
 	subID, err := uuid.Parse(subIDStr)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("%s: %v", ErrInvalidSubID, err), http.StatusBadRequest)
+		api.WriteFieldError(w, r, "id", ErrInvalidSubID)
+		return
+	}
+
+	versions, err := parseIfMatch(r)
+	if err != nil {
+		api.WriteFieldError(w, r, "If-Match", err.Error())
 		return
 	}
 
 	var req domain.UpdateSubRequest
 	defer r.Body.Close()
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, fmt.Sprintf("%s: %v", ErrInvalidBody, err), http.StatusBadRequest)
+		api.WriteBodyError(w, r, err)
+		return
+	}
+
+	req.AllowOverlap, err = parseOptionalBoolParam(r, "allow_overlap")
+	if err != nil {
+		api.WriteFieldError(w, r, "allow_overlap", err.Error())
 		return
 	}
 
-	// Преобразуем даты перед передачей в сервис
-	if req.StartDate != nil {
-		startDate, err := utils.ParseMonthYear(*req.StartDate)
+	dateFormat, err := api.ParseDateFormat(r)
+	if err != nil {
+		api.WriteFieldError(w, r, "date_format", err.Error())
+		return
+	}
+
+	// Преобразуем даты перед передачей в сервис, null оставляем как есть.
+	// Запоминаем формат переданных дат, чтобы отвечать в нём же, приоритет у start_date
+	if req.BillingAnchor.HasValue() {
+		billingAnchor, _, format, err := utils.ParsePeriod(req.BillingAnchor.Value)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("invalid start date: %v", err), http.StatusBadRequest)
+			api.WriteFieldError(w, r, "billing_anchor", err.Error())
 			return
 		}
-		*req.StartDate = startDate.Format("2006-01-02") // Преобразуем в YYYY-MM-DD
+		req.BillingAnchor.Value = utils.ToDateString(billingAnchor) // Преобразуем в YYYY-MM-DD
+		req.DateFormat = format
 	}
 
-	if req.EndDate != nil {
-		endDate, err := utils.ParseMonthYearToEndOfMonth(*req.EndDate)
+	if req.EndDate.HasValue() {
+		_, endDate, format, err := utils.ParsePeriod(req.EndDate.Value)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("invalid end date: %v", err), http.StatusBadRequest)
+			api.WriteFieldError(w, r, "end_date", err.Error())
 			return
 		}
-		*req.EndDate = endDate.Format("2006-01-02") // Преобразуем в YYYY-MM-DD
+		req.EndDate.Value = utils.ToDateString(endDate) // Преобразуем в YYYY-MM-DD
+		req.DateFormat = format
 	}
 
-	updatedSub, err := h.service.UpdateSub(r.Context(), subID, &req)
+	if req.PriceEffectiveFrom.HasValue() {
+		effectiveFrom, _, _, err := utils.ParsePeriod(req.PriceEffectiveFrom.Value)
+		if err != nil {
+			api.WriteFieldError(w, r, "price_effective_from", err.Error())
+			return
+		}
+		req.PriceEffectiveFrom.Value = utils.ToDateString(effectiveFrom) // Преобразуем в YYYY-MM-DD
+	}
+
+	if req.StartDate.HasValue() {
+		startDate, _, format, err := utils.ParsePeriod(req.StartDate.Value)
+		if err != nil {
+			api.WriteFieldError(w, r, "start_date", err.Error())
+			return
+		}
+		req.StartDate.Value = utils.ToDateString(startDate) // Преобразуем в YYYY-MM-DD
+		req.DateFormat = format
+	}
+
+	updatedSub, err := h.service.UpdateSub(r.Context(), subID, &req, versions)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to update subscription: %v", err), http.StatusInternalServerError)
+		api.WriteServiceError(w, r, err)
 		return
 	}
 
-	response := domain.ConvertSubToResponse(updatedSub)
+	today := domain.Today()
+	setETag(w, updatedSub, dateFormat, today)
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, fmt.Sprintf("%s: %v", ErrInternalServer, err), http.StatusInternalServerError)
+	response := domain.ConvertSubToResponse(updatedSub, dateFormat, today)
+
+	api.WriteJSON(w, http.StatusOK, response)
+}
+
+// PauseSub godoc
+// @Summary Pause subscription
+// @Description Pause subscription from from (today by default) to to inclusive or until resumed if to is omitted.
+// @Description Charges on paused days are excluded from total cost.
+// @Tags subscriptions
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Subscription ID"
+// @Param If-Match header string false "ETag of the subscription to pause"
+// @Param input body domain.PauseSubRequest false "Pause interval"
+// @Param date_format query string false "Format of dates in response, the format dates were sent in by default" Enums(YYYY-MM-DD, YYYY-MM, MM-YYYY)
+// @Param X-Date-Format header string false "Format of dates in response if date_format is not set" Enums(YYYY-MM-DD, YYYY-MM, MM-YYYY)
+// @Success 200 {object} domain.SubResponse "Paused subscription"
+// @Failure 400 {object} api.Problem "Invalid input"
+// @Failure 404 {object} api.Problem "Subscription not found"
+// @Failure 409 {object} api.Problem "Subscription is already paused in this period"
+// @Failure 412 {object} api.Problem "Subscription version does not match If-Match"
+// @Failure 422 {object} api.Problem "Validation failed"
+// @Failure 503 {object} api.Problem "Database unavailable"
+// @Failure 500 {object} api.Problem "Internal server error"
+// @Router /subs/{id}/pause [post]
+func (h *HandlerSub) PauseSub(w http.ResponseWriter, r *http.Request) {
+	subIDStr := chi.URLParam(r, "id")
+	subID, err := uuid.Parse(subIDStr)
+	if err != nil {
+		api.WriteFieldError(w, r, "id", ErrInvalidSubID)
+		return
+	}
+
+	versions, err := parseIfMatch(r)
+	if err != nil {
+		api.WriteFieldError(w, r, "If-Match", err.Error())
+		return
 	}
+
+	dateFormat, err := api.ParseDateFormat(r)
+	if err != nil {
+		api.WriteFieldError(w, r, "date_format", err.Error())
+		return
+	}
+
+	// Тело запроса необязательно, без него пауза начинается сегодня и длится до возобновления
+	var req domain.PauseSubRequest
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		api.WriteBodyError(w, r, err)
+		return
+	}
+
+	from := domain.Today()
+	if req.From != nil {
+		from, _, _, err = utils.ParsePeriod(*req.From)
+		if err != nil {
+			api.WriteFieldError(w, r, "from", err.Error())
+			return
+		}
+	}
+
+	var to *time.Time
+	if req.To != nil {
+		_, parsed, _, err := utils.ParsePeriod(*req.To)
+		if err != nil {
+			api.WriteFieldError(w, r, "to", err.Error())
+			return
+		}
+		to = &parsed
+	}
+
+	sub, err := h.service.PauseSub(r.Context(), subID, from, to, versions)
+	if err != nil {
+		api.WriteServiceError(w, r, err)
+		return
+	}
+
+	today := domain.Today()
+	setETag(w, sub, dateFormat, today)
+
+	response := domain.ConvertSubToResponse(sub, dateFormat, today)
+
+	api.WriteJSON(w, http.StatusOK, response)
+}
+
+// ResumeSub godoc
+// @Summary Resume subscription
+// @Description Resume paused subscription from at (today by default): the pause in effect on at ends the day before it.
+// @Description If the subscription is not paused on at, the nearest pause that has not started by then is cancelled.
+// @Description Returns 409 if there is no such pause.
+// @Tags subscriptions
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Subscription ID"
+// @Param If-Match header string false "ETag of the subscription to resume"
+// @Param input body domain.ResumeSubRequest false "Resume date"
+// @Param date_format query string false "Format of dates in response, the format dates were sent in by default" Enums(YYYY-MM-DD, YYYY-MM, MM-YYYY)
+// @Param X-Date-Format header string false "Format of dates in response if date_format is not set" Enums(YYYY-MM-DD, YYYY-MM, MM-YYYY)
+// @Success 200 {object} domain.SubResponse "Resumed subscription"
+// @Failure 400 {object} api.Problem "Invalid input"
+// @Failure 404 {object} api.Problem "Subscription not found"
+// @Failure 409 {object} api.Problem "Subscription is not paused and has no upcoming pause"
+// @Failure 412 {object} api.Problem "Subscription version does not match If-Match"
+// @Failure 503 {object} api.Problem "Database unavailable"
+// @Failure 500 {object} api.Problem "Internal server error"
+// @Router /subs/{id}/resume [post]
+func (h *HandlerSub) ResumeSub(w http.ResponseWriter, r *http.Request) {
+	subIDStr := chi.URLParam(r, "id")
+	subID, err := uuid.Parse(subIDStr)
+	if err != nil {
+		api.WriteFieldError(w, r, "id", ErrInvalidSubID)
+		return
+	}
+
+	versions, err := parseIfMatch(r)
+	if err != nil {
+		api.WriteFieldError(w, r, "If-Match", err.Error())
+		return
+	}
+
+	dateFormat, err := api.ParseDateFormat(r)
+	if err != nil {
+		api.WriteFieldError(w, r, "date_format", err.Error())
+		return
+	}
+
+	var req domain.ResumeSubRequest
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		api.WriteBodyError(w, r, err)
+		return
+	}
+
+	at := domain.Today()
+	if req.At != nil {
+		at, _, _, err = utils.ParsePeriod(*req.At)
+		if err != nil {
+			api.WriteFieldError(w, r, "at", err.Error())
+			return
+		}
+	}
+
+	sub, err := h.service.ResumeSub(r.Context(), subID, at, versions)
+	if err != nil {
+		api.WriteServiceError(w, r, err)
+		return
+	}
+
+	today := domain.Today()
+	setETag(w, sub, dateFormat, today)
+
+	response := domain.ConvertSubToResponse(sub, dateFormat, today)
+
+	api.WriteJSON(w, http.StatusOK, response)
 }
 
 // DeleteSub godoc
 // @Summary Delete subscription
-// @Description Delete existing subscription
+// @Description Soft delete existing subscription, it can be restored until purged
 // @Tags subscriptions
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Subscription ID"
+// @Param If-Match header string false "ETag of the subscription to delete"
 // @Success 204 "No content"
-// @Failure 400 {string} string "Invalid subscription ID"
-// @Failure 404 {string} string "Subscription not found"
-// @Failure 500 {string} string "Internal server error"
-// @Router /delete/{id} [delete]
+// @Failure 400 {object} api.Problem "Invalid subscription ID"
+// @Failure 404 {object} api.Problem "Subscription not found"
+// @Failure 412 {object} api.Problem "Subscription version does not match If-Match"
+// @Failure 422 {object} api.Problem "Validation failed"
+// @Failure 503 {object} api.Problem "Database unavailable"
+// @Failure 500 {object} api.Problem "Internal server error"
+// @Router /subs/delete/{id} [delete]
 func (h *HandlerSub) DeleteSub(w http.ResponseWriter, r *http.Request) {
 	subIDStr := chi.URLParam(r, "id")
 	subID, err := uuid.Parse(subIDStr)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("%s: %v", ErrInvalidSubID, err), http.StatusBadRequest)
+		api.WriteFieldError(w, r, "id", ErrInvalidSubID)
+		return
+	}
+
+	versions, err := parseIfMatch(r)
+	if err != nil {
+		api.WriteFieldError(w, r, "If-Match", err.Error())
 		return
 	}
 
-	if err := h.service.DeleteSub(r.Context(), subID); err != nil {
-		http.Error(w, fmt.Sprintf("failed to delete subscription: %v", err), http.StatusInternalServerError)
+	if err := h.service.DeleteSub(r.Context(), subID, versions); err != nil {
+		api.WriteServiceError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// RestoreSub godoc
+// @Summary Restore subscription
+// @Description Restore soft deleted subscription.
+// @Description Returns 409 with conflicting_id if it overlaps with a subscription of the same user and service created meanwhile.
+// @Tags subscriptions
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Subscription ID"
+// @Param date_format query string false "Format of dates in response, the format dates were sent in by default" Enums(YYYY-MM-DD, YYYY-MM, MM-YYYY)
+// @Param X-Date-Format header string false "Format of dates in response if date_format is not set" Enums(YYYY-MM-DD, YYYY-MM, MM-YYYY)
+// @Success 200 {object} domain.SubResponse "Restored subscription"
+// @Failure 400 {object} api.Problem "Invalid subscription ID"
+// @Failure 404 {object} api.Problem "Subscription not found"
+// @Failure 409 {object} api.Problem "Subscription is not deleted or overlaps with subscription conflicting_id of the same user and service"
+// @Failure 503 {object} api.Problem "Database unavailable"
+// @Failure 500 {object} api.Problem "Internal server error"
+// @Router /subs/restore/{id} [post]
+func (h *HandlerSub) RestoreSub(w http.ResponseWriter, r *http.Request) {
+	subIDStr := chi.URLParam(r, "id")
+	subID, err := uuid.Parse(subIDStr)
+	if err != nil {
+		api.WriteFieldError(w, r, "id", ErrInvalidSubID)
+		return
+	}
+
+	dateFormat, err := api.ParseDateFormat(r)
+	if err != nil {
+		api.WriteFieldError(w, r, "date_format", err.Error())
+		return
+	}
+
+	sub, err := h.service.RestoreSub(r.Context(), subID)
+	if err != nil {
+		api.WriteServiceError(w, r, err)
+		return
+	}
+
+	today := domain.Today()
+	setETag(w, sub, dateFormat, today)
+
+	response := domain.ConvertSubToResponse(sub, dateFormat, today)
+
+	api.WriteJSON(w, http.StatusOK, response)
+}
+
+// PurgeDeletedSubs godoc
+// @Summary Purge deleted subscriptions
+// @Description Permanently remove subscriptions deleted more than older_than ago (for administrators)
+// @Tags subscriptions
+// @Accept  json
+// @Produce  json
+// @Param older_than query string true "Retention period, e.g. 720h"
+// @Success 200 {object} domain.PurgeResponse "Number of purged subscriptions"
+// @Failure 400 {object} api.Problem "Invalid retention period"
+// @Failure 503 {object} api.Problem "Database unavailable"
+// @Failure 500 {object} api.Problem "Internal server error"
+// @Router /subs/purge [post]
+func (h *HandlerSub) PurgeDeletedSubs(w http.ResponseWriter, r *http.Request) {
+	retention, err := time.ParseDuration(r.URL.Query().Get("older_than"))
+	if err != nil || retention < 0 {
+		api.WriteFieldError(w, r, "older_than", "must be a non-negative duration, e.g. 720h")
+		return
+	}
+
+	purged, err := h.service.PurgeDeletedSubs(r.Context(), retention)
+	if err != nil {
+		api.WriteServiceError(w, r, err)
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, domain.PurgeResponse{Purged: purged})
+}
+
 // CalculateTotalCost godoc
 // @Summary Calculate total cost
-// @Description Calculate total cost of subscriptions for given period
+// @Description Calculate total cost of subscriptions for given period.
+// @Description Every charge of a subscription within the period is counted according to its billing period
+// @Description and converted to target_currency at the exchange rate effective on the charge date.
+// @Description With proration billing_day (default) the full price is counted on every billing day within the period,
+// @Description with daily the price of every billing cycle is prorated by days the subscription is active in it.
+// @Description Returns 422 if an exchange rate is missing.
+// @Description Set breakdown to true to get per-month costs.
+// @Description Set breakdown_by to category or tag to get costs per category or per tag, a subscription with several
+// @Description tags is counted in each of them, so the sum of tag groups may exceed the total.
+// @Description Set group_by to any combination of user_id, service_name, category and month to get rows of totals
+// @Description and numbers of subscriptions per combination of keys, service_name groups by catalog service.
+// @Description Rows are ordered by order_by (total, count or a group_by key) in order (desc by default)
+// @Description and limited to limit rows (at most and by default 1000).
 // @Tags subscriptions
 // @Accept  json
 // @Produce  json
 // @Param input body domain.TotalCostFilter true "Filter parameters"
-// @Success 200 {object} map[string]int "Total cost"
-// @Failure 400 {string} string "Invalid input"
-// @Failure 500 {string} string "Internal server error"
-// @Router /total [post]
+// @Param date_format query string false "Format of dates in response, the format dates were sent in by default" Enums(YYYY-MM-DD, YYYY-MM, MM-YYYY)
+// @Param X-Date-Format header string false "Format of dates in response if date_format is not set" Enums(YYYY-MM-DD, YYYY-MM, MM-YYYY)
+// @Success 200 {object} domain.TotalCostResponse "Total cost"
+// @Failure 400 {object} api.Problem "Invalid input"
+// @Failure 422 {object} api.Problem "Validation failed"
+// @Failure 503 {object} api.Problem "Database unavailable"
+// @Failure 500 {object} api.Problem "Internal server error"
+// @Router /subs/total [post]
 func (h *HandlerSub) CalculateTotalCost(w http.ResponseWriter, r *http.Request) {
 	var filter domain.TotalCostFilter
 	defer r.Body.Close()
 	if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
-		http.Error(w, fmt.Sprintf("%s: %v", ErrInvalidBody, err), http.StatusBadRequest)
+		api.WriteBodyError(w, r, err)
 		return
 	}
 
-	// Преобразуем даты в формат, понятный БД
-	if filter.StartPeriod != "" {
-		startDate, err := utils.ParseMonthYear(filter.StartPeriod)
-		if err != nil {
-			http.Error(w, fmt.Sprintf("invalid start period: %v", err), http.StatusBadRequest)
-			return
-		}
-		filter.StartPeriod = startDate.Format("2006-01-02") // Преобразуем в YYYY-MM-DD
+	dateFormat, err := api.ParseDateFormat(r)
+	if err != nil {
+		api.WriteFieldError(w, r, "date_format", err.Error())
+		return
 	}
 
-	if filter.EndPeriod != "" {
-		endDate, err := utils.ParseMonthYearToEndOfMonth(filter.EndPeriod)
-		if err != nil {
-			http.Error(w, fmt.Sprintf("invalid end period: %v", err), http.StatusBadRequest)
-			return
-		}
-		filter.EndPeriod = endDate.Format("2006-01-02") // Преобразуем в YYYY-MM-DD
+	// Преобразуем даты в формат, понятный БД, месяц без дня означает весь месяц
+	startDate, _, startFormat, err := utils.ParsePeriod(filter.StartPeriod)
+	if err != nil {
+		api.WriteFieldError(w, r, "start_period", err.Error())
+		return
+	}
+	if dateFormat == "" {
+		dateFormat = startFormat
 	}
 
-	total, err := h.service.CalculateTotalCost(r.Context(), filter)
+	_, endDate, _, err := utils.ParsePeriod(filter.EndPeriod)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("failed to calculate total cost: %v", err), http.StatusInternalServerError)
+		api.WriteFieldError(w, r, "end_period", err.Error())
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(map[string]int{
-		"total_cost": total,
-	}); err != nil {
-		http.Error(w, fmt.Sprintf("%s: %v", ErrInternalServer, err), http.StatusInternalServerError)
+	if endDate.Before(startDate) {
+		api.WriteFieldError(w, r, "end_period", ErrInvalidDateRange+": end period is before start period")
+		return
+	}
+
+	filter.TargetCurrency = strings.ToUpper(strings.TrimSpace(filter.TargetCurrency))
+	if filter.TargetCurrency == "" {
+		filter.TargetCurrency = domain.BaseCurrency
+	}
+	if !domain.ValidCurrency(filter.TargetCurrency) {
+		api.WriteFieldError(w, r, "target_currency", "must be ISO-4217 currency code")
+		return
+	}
+
+	if filter.Proration == "" {
+		filter.Proration = domain.ProrationBillingDay
+	}
+	if !filter.Proration.Valid() {
+		api.WriteFieldError(w, r, "proration", "must be billing_day or daily")
+		return
 	}
+
+	if filter.BreakdownBy != "" && !filter.BreakdownBy.Valid() {
+		api.WriteFieldError(w, r, "breakdown_by", "must be category or tag")
+		return
+	}
+
+	if verr := validateCostRows(&filter); verr != nil {
+		api.WriteInvalidParams(w, r, verr)
+		return
+	}
+
+	filter.Category = domain.NormalizeCategory(filter.Category)
+	if filter.Category != nil && *filter.Category == "" {
+		api.WriteFieldError(w, r, "category", "must not be empty")
+		return
+	}
+	filter.Tags = domain.NormalizeTags(filter.Tags)
+
+	filter.StartPeriod = utils.ToDateString(startDate) // Преобразуем в YYYY-MM-DD
+	filter.EndPeriod = utils.ToDateString(endDate)     // Преобразуем в YYYY-MM-DD
+
+	total, err := h.service.CalculateTotalCost(r.Context(), filter)
+	if err != nil {
+		api.WriteServiceError(w, r, err)
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, domain.ConvertTotalCostToResponse(total, filter.Breakdown, dateFormat))
 }
@@ -0,0 +1,250 @@
+package sub
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/domain"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/utils"
+)
+
+// parseListParams разбирает параметры пагинации, сортировки и фильтрации из query string
+func parseListParams(r *http.Request) (domain.ListSubsParams, *domain.ValidationError) {
+	query := r.URL.Query()
+	verr := domain.NewValidationError()
+
+	params := domain.ListSubsParams{
+		Sort:  domain.SortByStartDate,
+		Limit: domain.DefaultListLimit,
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > domain.MaxListLimit {
+			verr.Add("limit", "must be an integer between 1 and "+strconv.Itoa(domain.MaxListLimit))
+		} else {
+			params.Limit = limit
+		}
+	}
+
+	if v := query.Get("sort"); v != "" {
+		params.Sort = domain.SortField(v)
+		if !params.Sort.Valid() {
+			verr.Add("sort", "must be one of price, start_date, service_name")
+		}
+	}
+
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		params.Desc = true
+	default:
+		verr.Add("order", "must be asc or desc")
+	}
+
+	if v := query.Get("cursor"); v != "" {
+		cursor, err := domain.DecodeCursor(v)
+		switch {
+		case err != nil:
+			verr.Add("cursor", err.Error())
+		case cursor.Sort != params.Sort || cursor.Desc != params.Desc:
+			verr.Add("cursor", "does not match sort and order")
+		default:
+			params.Cursor = cursor
+		}
+	}
+
+	if v := query.Get("service_name"); v != "" {
+		params.ServiceName = &v
+	}
+
+	if v := query.Get("service_id"); v != "" {
+		serviceID, err := uuid.Parse(v)
+		if err != nil {
+			verr.Add("service_id", "must be UUID")
+		} else {
+			params.ServiceID = &serviceID
+		}
+	}
+
+	if query.Has("category") {
+		v := query.Get("category")
+		params.Category = domain.NormalizeCategory(&v)
+		if *params.Category == "" {
+			verr.Add("category", "must not be empty")
+		}
+	}
+
+	var tags []string
+	for _, v := range query["tags"] {
+		tags = append(tags, strings.Split(v, ",")...)
+	}
+	if len(tags) > 0 {
+		params.Tags = domain.NormalizeTags(tags)
+	}
+
+	if v := query.Get("min_price"); v != "" {
+		price, err := strconv.ParseFloat(v, 64)
+		if err != nil || math.IsNaN(price) || math.IsInf(price, 0) {
+			verr.Add("min_price", "must be a decimal amount")
+		} else {
+			params.MinPrice = &price
+		}
+	}
+
+	if v := query.Get("max_price"); v != "" {
+		price, err := strconv.ParseFloat(v, 64)
+		if err != nil || math.IsNaN(price) || math.IsInf(price, 0) {
+			verr.Add("max_price", "must be a decimal amount")
+		} else {
+			params.MaxPrice = &price
+		}
+	}
+
+	if params.MinPrice != nil && params.MaxPrice != nil && *params.MinPrice > *params.MaxPrice {
+		verr.Add("max_price", "must not be less than min_price")
+	}
+
+	if v := query.Get("active_at"); v != "" {
+		from, to, _, err := utils.ParsePeriod(v)
+		if err != nil {
+			verr.Add("active_at", err.Error())
+		} else {
+			params.ActiveFrom = &from
+			params.ActiveTo = &to
+		}
+	}
+
+	if v := query.Get("ended"); v != "" {
+		ended, err := strconv.ParseBool(v)
+		if err != nil {
+			verr.Add("ended", "must be true or false")
+		} else {
+			params.Ended = &ended
+		}
+	}
+
+	for _, v := range query["status"] {
+		for _, status := range strings.Split(v, ",") {
+			status := domain.SubStatus(strings.TrimSpace(status))
+			if !status.Valid() {
+				verr.Add("status", "must be one of scheduled, active, ending_soon, ended, paused")
+				break
+			}
+			params.Statuses = append(params.Statuses, status)
+		}
+	}
+
+	params.StatusAt = domain.Today()
+	if v := query.Get("status_at"); v != "" {
+		statusAt, _, _, err := utils.ParsePeriod(v)
+		if err != nil {
+			verr.Add("status_at", err.Error())
+		} else {
+			params.StatusAt = statusAt
+		}
+	}
+
+	includeDeleted, err := parseBoolParam(r, "include_deleted")
+	if err != nil {
+		verr.Add("include_deleted", err.Error())
+	}
+	params.IncludeDeleted = includeDeleted
+
+	if len(verr.Fields) > 0 {
+		return params, verr
+	}
+
+	return params, nil
+}
+
+// parseBoolParam разбирает необязательный логический параметр query string
+func parseBoolParam(r *http.Request, name string) (bool, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return false, nil
+	}
+
+	value, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, errors.New("must be true or false")
+	}
+
+	return value, nil
+}
+
+// parseOptionalBoolParam разбирает необязательный логический параметр query string,
+// nil означает, что параметр не передан
+func parseOptionalBoolParam(r *http.Request, name string) (*bool, error) {
+	if r.URL.Query().Get(name) == "" {
+		return nil, nil
+	}
+
+	value, err := parseBoolParam(r, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &value, nil
+}
+
+// validateCostRows проверяет ключи группировки, сортировку и ограничение числа строк итогов
+// и подставляет значения по умолчанию
+func validateCostRows(filter *domain.TotalCostFilter) *domain.ValidationError {
+	verr := domain.NewValidationError()
+
+	keys := make(map[domain.CostGroupKey]bool, len(filter.GroupBy))
+	for i, key := range filter.GroupBy {
+		field := fmt.Sprintf("group_by[%d]", i)
+		switch {
+		case !key.Valid():
+			verr.Add(field, "must be one of user_id, service_name, category, month")
+		case keys[key]:
+			verr.Add(field, "must not repeat")
+		}
+		keys[key] = true
+	}
+
+	grouped := len(filter.GroupBy) > 0
+	if !grouped && (filter.OrderBy != "" || filter.Order != "" || filter.Limit != 0) {
+		verr.Add("group_by", "must not be empty with order_by, order or limit")
+	}
+
+	filter.OrderBy = strings.ToLower(strings.TrimSpace(filter.OrderBy))
+	switch filter.OrderBy {
+	case "":
+		filter.OrderBy = domain.CostOrderByTotal
+	case domain.CostOrderByTotal, domain.CostOrderByCount:
+	default:
+		if !keys[domain.CostGroupKey(filter.OrderBy)] {
+			verr.Add("order_by", "must be total, count or one of group_by keys")
+		}
+	}
+
+	filter.Order = strings.ToLower(strings.TrimSpace(filter.Order))
+	switch filter.Order {
+	case "":
+		filter.Order = "desc"
+	case "asc", "desc":
+	default:
+		verr.Add("order", "must be asc or desc")
+	}
+
+	switch {
+	case filter.Limit == 0:
+		filter.Limit = domain.MaxCostRows
+	case filter.Limit < 0 || filter.Limit > domain.MaxCostRows:
+		verr.Add("limit", fmt.Sprintf("must be between 1 and %d", domain.MaxCostRows))
+	}
+
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+
+	return nil
+}
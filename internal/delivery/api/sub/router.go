@@ -3,11 +3,14 @@ package sub
 import (
 	"github.com/go-chi/chi/v5"
 	_ "github.com/maYkiss56/subscription-aggregation-service/docs"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/delivery/api"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
 func NewRouter(subs *HandlerSub) chi.Router {
 	r := chi.NewRouter()
+	r.NotFound(api.NotFound)
+	r.MethodNotAllowed(api.MethodNotAllowed)
 
 	// Swagger
 	r.Get("/swagger/*", httpSwagger.Handler(
@@ -18,10 +21,16 @@ func NewRouter(subs *HandlerSub) chi.Router {
 
 		r.Get("/", subs.GetAllSubs)
 		r.Get("/{user_id}", subs.GetSubByUserID)
+		r.Get("/id/{id}", subs.GetSubByID)
+		r.Get("/prices/{id}", subs.GetPriceTimeline)
 		r.Post("/total", subs.CalculateTotalCost)
 		r.Post("/create", subs.CreateSub)
 		r.Patch("/update/{id}", subs.UpdateSub)
 		r.Delete("/delete/{id}", subs.DeleteSub)
+		r.Post("/restore/{id}", subs.RestoreSub)
+		r.Post("/{id}/pause", subs.PauseSub)
+		r.Post("/{id}/resume", subs.ResumeSub)
+		r.Post("/purge", subs.PurgeDeletedSubs)
 	})
 
 	return r
@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"time"
+)
+
+// BillingPeriod represents how often subscription is charged
+type BillingPeriod string
+
+const (
+	BillingWeekly       BillingPeriod = "weekly"
+	BillingMonthly      BillingPeriod = "monthly"
+	BillingQuarterly    BillingPeriod = "quarterly"
+	BillingYearly       BillingPeriod = "yearly"
+	BillingEveryNMonths BillingPeriod = "every_n_months"
+)
+
+func (p BillingPeriod) Valid() bool {
+	switch p {
+	case BillingWeekly, BillingMonthly, BillingQuarterly, BillingYearly, BillingEveryNMonths:
+		return true
+	default:
+		return false
+	}
+}
+
+// Billing represents billing schedule of subscription: price is charged every period
+// starting from anchor, Interval is the number of months for every_n_months period
+type Billing struct {
+	Period   BillingPeriod
+	Interval int
+	Anchor   time.Time
+}
+
+// NewBilling возвращает расписание списаний, по умолчанию ежемесячное с якорем в дату начала подписки
+func NewBilling(period BillingPeriod, interval int, anchor *time.Time, startDate time.Time) Billing {
+	billing := Billing{
+		Period:   period,
+		Interval: interval,
+		Anchor:   startDate,
+	}
+
+	if billing.Period == "" {
+		billing.Period = BillingMonthly
+	}
+	if billing.Interval == 0 && billing.Period != BillingEveryNMonths {
+		billing.Interval = 1
+	}
+	if anchor != nil {
+		billing.Anchor = *anchor
+	}
+
+	return billing
+}
+
+// validate добавляет ошибки полей расписания списаний подписки, начинающейся в startDate
+func (b Billing) validate(verr *ValidationError, startDate time.Time) {
+	if b.Anchor.Before(startDate) {
+		verr.Add("billing_anchor", "must not be before start_date")
+	}
+
+	if !b.Period.Valid() {
+		verr.Add("billing_period", "must be one of weekly, monthly, quarterly, yearly, every_n_months")
+		return
+	}
+
+	switch {
+	case b.Period == BillingEveryNMonths && b.Interval < 1:
+		verr.Add("billing_interval", "must be positive for every_n_months billing period")
+	case b.Period != BillingEveryNMonths && b.Interval != 1:
+		verr.Add("billing_interval", "is only allowed for every_n_months billing period")
+	}
+}
+
+// ProrationPolicy represents how charges of partially active billing cycles are counted in totals
+type ProrationPolicy string
+
+const (
+	// ProrationBillingDay counts full price for every billing day the subscription is active on
+	ProrationBillingDay ProrationPolicy = "billing_day"
+	// ProrationDaily counts price of every billing cycle proportionally to days the subscription
+	// is active within the cycle and the requested period
+	ProrationDaily ProrationPolicy = "daily"
+)
+
+func (p ProrationPolicy) Valid() bool {
+	switch p {
+	case ProrationBillingDay, ProrationDaily:
+		return true
+	default:
+		return false
+	}
+}
+
+// CostBreakdown represents labels total cost is broken down by
+type CostBreakdown string
+
+const (
+	BreakdownByCategory CostBreakdown = "category"
+	BreakdownByTag      CostBreakdown = "tag"
+)
+
+func (b CostBreakdown) Valid() bool {
+	switch b {
+	case BreakdownByCategory, BreakdownByTag:
+		return true
+	default:
+		return false
+	}
+}
+
+// CostGroupKey represents key total cost rows are grouped by
+type CostGroupKey string
+
+const (
+	GroupByUserID      CostGroupKey = "user_id"
+	GroupByServiceName CostGroupKey = "service_name"
+	GroupByCategory    CostGroupKey = "category"
+	GroupByMonth       CostGroupKey = "month"
+)
+
+func (k CostGroupKey) Valid() bool {
+	switch k {
+	case GroupByUserID, GroupByServiceName, GroupByCategory, GroupByMonth:
+		return true
+	default:
+		return false
+	}
+}
+
+// Поля сортировки строк итогов помимо ключей группировки
+const (
+	CostOrderByTotal = "total"
+	CostOrderByCount = "count"
+)
+
+// MaxCostRows is the maximal and default number of grouped total cost rows
+const MaxCostRows = 1000
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// BaseCurrency is the currency of subscriptions created without currency,
+// exchange rates are stored as the price of one unit of currency in BaseCurrency
+const BaseCurrency = "RUB"
+
+// ValidCurrency проверяет, что код валюты имеет формат ISO-4217 (три заглавные латинские буквы)
+func ValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// NormalizeCurrency приводит код валюты к верхнему регистру, пустой код означает BaseCurrency
+func NormalizeCurrency(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return BaseCurrency
+	}
+	return code
+}
+
+// ExchangeRate represents price of one unit of currency in BaseCurrency effective from date
+type ExchangeRate struct {
+	Currency string    `json:"currency" example:"USD"`
+	Date     time.Time `json:"date"`
+	Rate     float64   `json:"rate" example:"92.5"`
+}
+
+// Validate проверяет курс валюты
+func (r *ExchangeRate) Validate() error {
+	verr := NewValidationError()
+
+	if !ValidCurrency(r.Currency) {
+		verr.Add("currency", "must be ISO-4217 currency code")
+	}
+
+	if r.Date.IsZero() {
+		verr.Add("date", "must not be empty")
+	}
+
+	if r.Rate <= 0 {
+		verr.Add("rate", "must be positive")
+	}
+
+	return verr.OrNil()
+}
+
+// RatesFormat represents format of exchange rates data
+type RatesFormat string
+
+const (
+	RatesCSV  RatesFormat = "csv"
+	RatesJSON RatesFormat = "json"
+)
+
+// CurrencyCost represents cost of subscriptions charged in a single currency
+type CurrencyCost struct {
+	Currency  string
+	Cost      Money
+	Converted Money
+}
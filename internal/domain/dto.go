@@ -1,62 +1,602 @@
 package domain
 
 import (
+	"encoding/json"
+	"strings"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/maYkiss56/subscription-aggregation-service/internal/utils"
 )
 
 // CreateSubRequest represents request to create subscription
 type CreateSubRequest struct {
-	ServiceName string    `json:"service_name" example:"Netflix"`
-	Price       int       `json:"price" example:"1000"`
-	UserID      uuid.UUID `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	StartDate   string    `json:"start_date" example:"07-2025"`
-	EndDate     string    `json:"end_date" example:"07-2025"`
+	// ServiceName is matched to the services catalog by name or alias, unknown names are added to the catalog
+	ServiceName string `json:"service_name" example:"Netflix"`
+	// ServiceID is the catalog service, service_name defaults to its name
+	ServiceID *uuid.UUID `json:"service_id,omitempty" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+	// Price is decimal amount in major units of currency, as a number or a string
+	Price json.Number `json:"price" swaggertype:"string" example:"999.90"`
+	// Currency is ISO-4217 currency code, RUB by default
+	Currency string `json:"currency,omitempty" example:"RUB"`
+	// Phases are trial and promo prices charged from start_date before the regular price
+	Phases []PricePhaseRequest `json:"phases,omitempty"`
+	UserID uuid.UUID           `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	// StartDate is YYYY-MM-DD, YYYY-MM or MM-YYYY, month formats mean the first day of the month
+	StartDate string `json:"start_date" example:"2025-07-17"`
+	// EndDate is YYYY-MM-DD, YYYY-MM or MM-YYYY, month formats mean the last day of the month
+	EndDate *string `json:"end_date,omitempty" example:"2026-07-16"`
+	// BillingPeriod is one of weekly, monthly, quarterly, yearly, every_n_months, monthly by default
+	BillingPeriod   BillingPeriod `json:"billing_period,omitempty" example:"monthly"`
+	BillingInterval int           `json:"billing_interval,omitempty" example:"2"`
+	// BillingAnchor is the date of the first charge, not before start_date, start_date by default
+	BillingAnchor *string `json:"billing_anchor,omitempty" example:"2025-07-17"`
+	// Category is the category of the catalog service by default
+	Category *string `json:"category,omitempty" example:"entertainment"`
+	// Tags are custom labels without spaces and commas, case is ignored
+	Tags []string `json:"tags,omitempty" example:"shared-with-partner"`
 }
 
-// UpdateSubRequest represents request to update subscription
+// UpdateSubRequest represents JSON Merge Patch (RFC 7396) request to update subscription:
+// omitted fields stay as they are, explicit null clears nullable fields
 type UpdateSubRequest struct {
-	ServiceName *string `json:"service_name,omitempty" example:"Netflix Premium"`
-	Price       *int    `json:"price,omitempty" example:"1500"`
-	StartDate   *string `json:"start_date,omitempty" example:"07-2025"`
-	EndDate     *string `json:"end_date,omitempty" example:"07-2025"`
+	// ServiceName is matched to the services catalog again unless service_id is set
+	ServiceName Nullable[string]      `json:"service_name" swaggertype:"string" example:"Netflix Premium"`
+	ServiceID   Nullable[uuid.UUID]   `json:"service_id" swaggertype:"string" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+	Price       Nullable[json.Number] `json:"price" swaggertype:"string" example:"1499.90"`
+	// PriceEffectiveFrom is the date new price is charged from, today or start_date if it is later by default
+	PriceEffectiveFrom Nullable[string] `json:"price_effective_from" swaggertype:"string" example:"2025-03-01"`
+	// Currency applies to the whole price history and can only be changed before start_date
+	Currency  Nullable[string] `json:"currency" swaggertype:"string" example:"USD"`
+	StartDate Nullable[string] `json:"start_date" swaggertype:"string" example:"2025-07-17"`
+	EndDate   Nullable[string] `json:"end_date" swaggertype:"string" example:"2026-07-16" extensions:"x-nullable"`
+	// Phases replace all price phases, null removes them
+	Phases Nullable[[]PricePhaseRequest] `json:"phases" swaggertype:"array,object" extensions:"x-nullable"`
+
+	BillingPeriod   Nullable[string] `json:"billing_period" swaggertype:"string" example:"yearly"`
+	BillingInterval Nullable[int]    `json:"billing_interval" swaggertype:"integer" example:"2" extensions:"x-nullable"`
+	// BillingAnchor null resets anchor to start_date. An anchor equal to start_date moves with it
+	BillingAnchor Nullable[string] `json:"billing_anchor" swaggertype:"string" example:"2025-07-17" extensions:"x-nullable"`
+
+	Category Nullable[string] `json:"category" swaggertype:"string" example:"work tools" extensions:"x-nullable"`
+	// Tags replace all tags, null removes them
+	Tags Nullable[[]string] `json:"tags" swaggertype:"array,string" extensions:"x-nullable"`
+
+	// DateFormat is the format of dates in the request, empty when no dates were sent
+	DateFormat utils.DateFormat `json:"-"`
+	// AllowOverlap allows or forbids the subscription to overlap with subscriptions of the same user
+	// and service, nil keeps it as is
+	AllowOverlap *bool `json:"-"`
+}
+
+// Normalize приводит поля запроса к каноничному виду
+func (r *UpdateSubRequest) Normalize() {
+	if r.ServiceName.HasValue() {
+		r.ServiceName.Value = strings.TrimSpace(r.ServiceName.Value)
+	}
+	if r.Currency.HasValue() {
+		r.Currency.Value = strings.ToUpper(strings.TrimSpace(r.Currency.Value))
+	}
+}
+
+// PricePhaseRequest represents trial or promo price phase, price is in major units of subscription currency
+type PricePhaseRequest struct {
+	Kind   PhaseKind   `json:"kind" example:"promo"`
+	Price  json.Number `json:"price" swaggertype:"string" example:"199"`
+	Months int         `json:"months" example:"3"`
+}
+
+// PricePhaseResponse represents price phase with its dates
+type PricePhaseResponse struct {
+	Kind      PhaseKind `json:"kind" example:"promo"`
+	Price     Money     `json:"price"`
+	Months    int       `json:"months" example:"3"`
+	StartDate string    `json:"start_date" example:"2025-08-17"`
+	EndDate   string    `json:"end_date" example:"2025-11-16"`
+}
+
+// PauseSubRequest represents request to pause subscription, dates are YYYY-MM-DD, YYYY-MM or MM-YYYY
+type PauseSubRequest struct {
+	// From is the first paused day, today by default
+	From *string `json:"from,omitempty" example:"2025-03-01"`
+	// To is the last paused day, the subscription is paused until resumed if omitted
+	To *string `json:"to,omitempty" example:"2025-05-31"`
+}
+
+// ResumeSubRequest represents request to resume paused subscription
+type ResumeSubRequest struct {
+	// At is the first active day after the pause, today by default
+	At *string `json:"at,omitempty" example:"2025-06-01"`
+}
+
+// PauseResponse represents pause of subscription, to is null until the subscription is resumed
+type PauseResponse struct {
+	From string  `json:"from" example:"2025-03-01"`
+	To   *string `json:"to" example:"2025-05-31"`
+}
+
+// PricePeriodResponse represents price in effect between dates, end_date is null for the last open-ended period
+type PricePeriodResponse struct {
+	Kind      PhaseKind `json:"kind" example:"regular"`
+	StartDate string    `json:"start_date" example:"2025-03-01"`
+	EndDate   *string   `json:"end_date" example:"2025-12-31"`
+	Price     Money     `json:"price"`
+}
+
+// ConvertPriceTimelineToResponse преобразует периоды цен подписки в список PricePeriodResponse
+func ConvertPriceTimelineToResponse(timeline []PricePeriod, format utils.DateFormat) []PricePeriodResponse {
+	result := make([]PricePeriodResponse, len(timeline))
+	for i, period := range timeline {
+		result[i] = PricePeriodResponse{
+			Kind:      period.Kind,
+			StartDate: utils.FormatDate(period.From, format),
+			Price:     period.Price,
+		}
+		if period.To != nil {
+			to := utils.FormatDate(*period.To, format)
+			result[i].EndDate = &to
+		}
+	}
+	return result
 }
 
 // TotalCostFilter represents filter for total cost calculation
 type TotalCostFilter struct {
 	UserID      *uuid.UUID `json:"user_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
-	ServiceName *string    `json:"service_name,omitempty" example:"Netflix"`
-	StartPeriod string     `json:"start_period" example:"07-2025"`
-	EndPeriod   string     `json:"end_period" example:"07-2025"`
+	ServiceName *string    `json:"service_name,omitempty" example:"Netflix"` // имя или псевдоним сервиса из каталога
+	ServiceID   *uuid.UUID `json:"service_id,omitempty" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+	StartPeriod string     `json:"start_period" example:"2025-07-01"` // YYYY-MM-DD, YYYY-MM или MM-YYYY
+	EndPeriod   string     `json:"end_period" example:"2025-12-31"`   // YYYY-MM-DD, YYYY-MM или MM-YYYY
+	Breakdown   bool       `json:"breakdown,omitempty" example:"true"`
+	// TargetCurrency is ISO-4217 currency code all charges are converted to, RUB by default
+	TargetCurrency string `json:"target_currency,omitempty" example:"USD"`
+	// Proration is billing_day (full price on every billing day, default) or daily (price of a billing cycle
+	// is prorated by days the subscription is active within the cycle and the period)
+	Proration ProrationPolicy `json:"proration,omitempty" example:"daily"`
+	Category  *string         `json:"category,omitempty" example:"entertainment"`
+	// Tags keep subscriptions that have all of them
+	Tags []string `json:"tags,omitempty" example:"shared-with-partner"`
+	// BreakdownBy is category or tag to get costs per category or per tag,
+	// subscriptions with several tags are counted in every tag
+	BreakdownBy CostBreakdown `json:"breakdown_by,omitempty" example:"category"`
+	// GroupBy is any combination of user_id, service_name, category and month to get totals per group in rows
+	GroupBy []CostGroupKey `json:"group_by,omitempty" example:"service_name,month"`
+	// OrderBy is total (default), count or one of group_by keys
+	OrderBy string `json:"order_by,omitempty" example:"total"`
+	// Order is asc or desc, desc by default
+	Order string `json:"order,omitempty" example:"desc"`
+	// Limit is the number of top rows, 1000 at most and by default
+	Limit int `json:"limit,omitempty" example:"10"`
+}
+
+// GroupCostResponse represents cost of subscriptions with the same category or tag,
+// key is null for subscriptions without category or tags
+type GroupCostResponse struct {
+	Key           *string `json:"key" example:"entertainment"`
+	Cost          Money   `json:"cost"`
+	Subscriptions int     `json:"subscriptions" example:"3"`
+}
+
+// MonthCostResponse represents cost for a single month in YYYY-MM or MM-YYYY format
+type MonthCostResponse struct {
+	Month string `json:"month" example:"2025-03"`
+	Cost  Money  `json:"cost"`
+}
+
+// CurrencyCostResponse represents subtotal of charges in a single currency
+type CurrencyCostResponse struct {
+	Currency  string `json:"currency" example:"USD"`
+	Cost      Money  `json:"cost"`
+	Converted Money  `json:"converted"`
 }
 
-// SubResponse представляет ответ с датами в формате MM-YYYY
+// TotalCostResponse represents total cost converted to target currency
+// with per-currency subtotals and optional per-month breakdown
+type TotalCostResponse struct {
+	TotalCost  Money                  `json:"total_cost"`
+	Currency   string                 `json:"currency" example:"RUB"`
+	Currencies []CurrencyCostResponse `json:"currencies"`
+	Months     []MonthCostResponse    `json:"months,omitempty"`
+	Groups     []GroupCostResponse    `json:"groups,omitempty"`
+	Rows       []CostRowResponse      `json:"rows,omitempty"`
+}
+
+// CostRowResponse represents total of charges with the same group_by keys, only keys from group_by are present,
+// grouping by service_name adds service_id of the catalog service. Charges without category are grouped
+// under the row without category. Count is the number of subscriptions charged
+type CostRowResponse struct {
+	UserID      *uuid.UUID `json:"user_id,omitempty"`
+	ServiceID   *uuid.UUID `json:"service_id,omitempty"`
+	ServiceName *string    `json:"service_name,omitempty"`
+	Category    *string    `json:"category,omitempty"`
+	Month       *string    `json:"month,omitempty" example:"2025-03"`
+	Total       Money      `json:"total"`
+	Count       int        `json:"count" example:"3"`
+}
+
+// SubResponse представляет ответ с датами в формате, в котором их передал клиент, или в запрошенном
 type SubResponse struct {
-	ID          uuid.UUID `json:"id"`
-	ServiceName string    `json:"service_name"`
-	Price       int       `json:"price"`
-	UserID      uuid.UUID `json:"user_id"`
-	StartDate   string    `json:"start_date"` // MM-YYYY
-	EndDate     string    `json:"end_date"`   // MM-YYYY
+	ID              uuid.UUID            `json:"id"`
+	ServiceName     string               `json:"service_name"`
+	ServiceID       uuid.UUID            `json:"service_id"`
+	Category        *string              `json:"category" example:"entertainment"`
+	Tags            []string             `json:"tags" example:"shared-with-partner"`
+	Price           Money                `json:"price"`         // регулярная цена, действующая сегодня
+	CurrentPrice    Money                `json:"current_price"` // цена с учётом фазы, действующей сегодня
+	Phases          []PricePhaseResponse `json:"phases"`
+	Status          SubStatus            `json:"status" example:"active"` // scheduled, active, ending_soon, ended или paused
+	Pauses          []PauseResponse      `json:"pauses"`
+	Currency        string               `json:"currency"`
+	UserID          uuid.UUID            `json:"user_id"`
+	StartDate       string               `json:"start_date" example:"2025-07-17"`
+	EndDate         *string              `json:"end_date" example:"2026-07-16"` // null для бессрочной подписки
+	BillingPeriod   BillingPeriod        `json:"billing_period"`
+	BillingInterval int                  `json:"billing_interval"`
+	BillingAnchor   string               `json:"billing_anchor" example:"2025-07-17"`
+	DateFormat      string               `json:"date_format" example:"YYYY-MM-DD"`
+	Version         int                  `json:"version"`
+	CreatedAt       time.Time            `json:"created_at"`
+	UpdatedAt       time.Time            `json:"updated_at"`
+	AllowOverlap    bool                 `json:"allow_overlap"`
+	DeletedAt       *time.Time           `json:"deleted_at,omitempty"`
 }
 
-// convertSubToResponse преобразует доменную Sub в SubResponse
-func ConvertSubToResponse(sub *Sub) *SubResponse {
-	return &SubResponse{
-		ID:          sub.ID,
-		ServiceName: sub.ServiceName,
-		Price:       sub.Price,
-		UserID:      sub.UserID,
-		StartDate:   utils.ToMonthYearString(sub.StartDate),
-		EndDate:     utils.ToMonthYearString(sub.EndDate),
+// convertSubToResponse преобразует доменную Sub в SubResponse с состоянием подписки на дату statusAt,
+// пустой format означает формат, в котором даты подписки передал клиент
+func ConvertSubToResponse(sub *Sub, format utils.DateFormat, statusAt time.Time) *SubResponse {
+	if format == "" {
+		format = sub.DateFormat
+	}
+
+	response := &SubResponse{
+		ID:              sub.ID,
+		ServiceName:     sub.ServiceName,
+		ServiceID:       sub.ServiceID,
+		Category:        sub.Category,
+		Tags:            sub.Tags,
+		Price:           sub.Price,
+		CurrentPrice:    sub.PriceAt(Today()),
+		Status:          sub.StatusAt(statusAt),
+		Currency:        sub.Price.Currency,
+		UserID:          sub.UserID,
+		StartDate:       utils.FormatDate(sub.StartDate, format),
+		BillingPeriod:   sub.Billing.Period,
+		BillingInterval: sub.Billing.Interval,
+		BillingAnchor:   utils.FormatDate(sub.Billing.Anchor, format),
+		DateFormat:      string(format),
+		Version:         sub.Version,
+		CreatedAt:       sub.CreatedAt,
+		UpdatedAt:       sub.UpdatedAt,
+		AllowOverlap:    sub.AllowOverlap,
+		DeletedAt:       sub.DeletedAt,
+	}
+
+	if sub.EndDate != nil {
+		endDate := utils.FormatDate(*sub.EndDate, format)
+		response.EndDate = &endDate
 	}
+
+	response.Pauses = make([]PauseResponse, len(sub.Pauses))
+	for i, pause := range sub.Pauses {
+		response.Pauses[i] = PauseResponse{From: utils.FormatDate(pause.From, format)}
+		if pause.To != nil {
+			to := utils.FormatDate(*pause.To, format)
+			response.Pauses[i].To = &to
+		}
+	}
+
+	response.Phases = make([]PricePhaseResponse, len(sub.Phases))
+	for i, phase := range sub.Phases {
+		response.Phases[i] = PricePhaseResponse{
+			Kind:      phase.Kind,
+			Price:     phase.Price,
+			Months:    phase.Months,
+			StartDate: utils.FormatDate(sub.PhaseStart(i), format),
+			EndDate:   utils.FormatDate(sub.PhaseStart(i+1).AddDate(0, 0, -1), format),
+		}
+	}
+
+	return response
 }
 
 // convertSubsToResponse преобразует список доменных Sub в список SubResponse
-func ConvertSubsToResponse(subs []*Sub) []*SubResponse {
+func ConvertSubsToResponse(subs []*Sub, format utils.DateFormat, statusAt time.Time) []*SubResponse {
 	result := make([]*SubResponse, len(subs))
 	for i, sub := range subs {
-		result[i] = ConvertSubToResponse(sub)
+		result[i] = ConvertSubToResponse(sub, format, statusAt)
 	}
 	return result
 }
+
+// ConvertTotalCostToResponse преобразует доменную TotalCost в TotalCostResponse
+func ConvertTotalCostToResponse(total *TotalCost, breakdown bool, format utils.DateFormat) *TotalCostResponse {
+	response := &TotalCostResponse{
+		TotalCost:  total.Total,
+		Currency:   total.Currency,
+		Currencies: make([]CurrencyCostResponse, len(total.Currencies)),
+	}
+
+	for i, subtotal := range total.Currencies {
+		response.Currencies[i] = CurrencyCostResponse{
+			Currency:  subtotal.Currency,
+			Cost:      subtotal.Cost,
+			Converted: subtotal.Converted,
+		}
+	}
+
+	for _, group := range total.Groups {
+		response.Groups = append(response.Groups, GroupCostResponse{
+			Key:           group.Key,
+			Cost:          group.Cost,
+			Subscriptions: group.Subscriptions,
+		})
+	}
+
+	// В строку попадают только ключи группировки
+	for _, row := range total.Rows {
+		rowResponse := CostRowResponse{
+			Total: row.Total,
+			Count: row.Count,
+		}
+		for _, key := range total.GroupBy {
+			switch key {
+			case GroupByUserID:
+				rowResponse.UserID = row.UserID
+			case GroupByServiceName:
+				rowResponse.ServiceID = row.ServiceID
+				rowResponse.ServiceName = row.ServiceName
+			case GroupByCategory:
+				rowResponse.Category = row.Category
+			case GroupByMonth:
+				if row.Month != nil {
+					month := utils.FormatMonth(*row.Month, format)
+					rowResponse.Month = &month
+				}
+			}
+		}
+		response.Rows = append(response.Rows, rowResponse)
+	}
+
+	if breakdown {
+		response.Months = make([]MonthCostResponse, len(total.Months))
+		for i, month := range total.Months {
+			response.Months[i] = MonthCostResponse{
+				Month: utils.FormatMonth(month.Month, format),
+				Cost:  month.Cost,
+			}
+		}
+	}
+
+	return response
+}
+
+// SubListResponse represents a page of subscriptions
+type SubListResponse struct {
+	Items      []*SubResponse `json:"items"`
+	NextCursor *string        `json:"next_cursor" example:"eyJzIjoicHJpY2UiLCJ2IjoiMTAwMCJ9"`
+}
+
+// ConvertSubPageToResponse преобразует страницу доменных Sub в SubListResponse
+func ConvertSubPageToResponse(page *SubPage, format utils.DateFormat, statusAt time.Time) *SubListResponse {
+	response := &SubListResponse{
+		Items: ConvertSubsToResponse(page.Subs, format, statusAt),
+	}
+
+	if page.NextCursor != nil {
+		cursor := page.NextCursor.Encode()
+		response.NextCursor = &cursor
+	}
+
+	return response
+}
+
+// PurgeResponse represents result of purging deleted subscriptions
+type PurgeResponse struct {
+	Purged int64 `json:"purged" example:"42"`
+}
+
+// RateRequest represents exchange rate in admin endpoint and rates JSON file
+type RateRequest struct {
+	Currency string  `json:"currency" example:"USD"`
+	Date     string  `json:"date" example:"2025-03-01"` // YYYY-MM-DD
+	Rate     float64 `json:"rate" example:"92.5"`
+}
+
+// RateResponse represents exchange rate with date in YYYY-MM-DD format
+type RateResponse struct {
+	Currency string  `json:"currency" example:"USD"`
+	Date     string  `json:"date" example:"2025-03-01"`
+	Rate     float64 `json:"rate" example:"92.5"`
+}
+
+// ConvertRatesToResponse преобразует курсы валют в список RateResponse
+func ConvertRatesToResponse(rates []ExchangeRate) []RateResponse {
+	result := make([]RateResponse, len(rates))
+	for i, rate := range rates {
+		result[i] = RateResponse{
+			Currency: rate.Currency,
+			Date:     utils.ToDateString(rate.Date),
+			Rate:     rate.Rate,
+		}
+	}
+	return result
+}
+
+// RatesResponse represents result of loading exchange rates
+type RatesResponse struct {
+	Loaded int `json:"loaded" example:"30"`
+}
+
+// CreateServiceRequest represents request to add service to the catalog
+type CreateServiceRequest struct {
+	Name string `json:"name" example:"Netflix"`
+	// Aliases are other names subscriptions to the service are matched by, case and spaces are ignored
+	Aliases  []string `json:"aliases,omitempty" example:"NFLX,Netflix Premium"`
+	Category *string  `json:"category,omitempty" example:"entertainment"`
+	Homepage *string  `json:"homepage,omitempty" example:"https://www.netflix.com"`
+	// DefaultPrice is the price suggested for new subscriptions to the service
+	DefaultPrice *Money `json:"default_price,omitempty"`
+}
+
+// UpdateServiceRequest represents JSON Merge Patch (RFC 7396) request to update catalog service
+type UpdateServiceRequest struct {
+	Name Nullable[string] `json:"name" swaggertype:"string" example:"Netflix"`
+	// Aliases replace all aliases, null removes them
+	Aliases      Nullable[[]string] `json:"aliases" swaggertype:"array,string" extensions:"x-nullable"`
+	Category     Nullable[string]   `json:"category" swaggertype:"string" example:"entertainment" extensions:"x-nullable"`
+	Homepage     Nullable[string]   `json:"homepage" swaggertype:"string" example:"https://www.netflix.com" extensions:"x-nullable"`
+	DefaultPrice Nullable[Money]    `json:"default_price" swaggertype:"object" extensions:"x-nullable"`
+}
+
+// ServiceResponse represents catalog service
+type ServiceResponse struct {
+	ID           uuid.UUID `json:"id" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+	Name         string    `json:"name" example:"Netflix"`
+	Aliases      []string  `json:"aliases" example:"NFLX,Netflix Premium"`
+	Category     *string   `json:"category" example:"entertainment"`
+	Homepage     *string   `json:"homepage" example:"https://www.netflix.com"`
+	DefaultPrice *Money    `json:"default_price"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ConvertServiceToResponse преобразует доменный Service в ServiceResponse
+func ConvertServiceToResponse(service *Service) *ServiceResponse {
+	aliases := service.Aliases
+	if aliases == nil {
+		aliases = []string{}
+	}
+
+	return &ServiceResponse{
+		ID:           service.ID,
+		Name:         service.Name,
+		Aliases:      aliases,
+		Category:     service.Category,
+		Homepage:     service.Homepage,
+		DefaultPrice: service.DefaultPrice,
+		CreatedAt:    service.CreatedAt,
+		UpdatedAt:    service.UpdatedAt,
+	}
+}
+
+// ConvertServicesToResponse преобразует список доменных Service в список ServiceResponse
+func ConvertServicesToResponse(services []*Service) []*ServiceResponse {
+	result := make([]*ServiceResponse, len(services))
+	for i, service := range services {
+		result[i] = ConvertServiceToResponse(service)
+	}
+	return result
+}
+
+// ServiceSuggestionResponse represents catalog service matching autocomplete query
+type ServiceSuggestionResponse struct {
+	Service *ServiceResponse `json:"service"`
+	// MatchedName is the name or alias of the service closest to the query
+	MatchedName string  `json:"matched_name" example:"YT Premium"`
+	Similarity  float64 `json:"similarity" example:"0.82"`
+}
+
+// ConvertServiceSuggestionsToResponse преобразует подсказки сервисов в список ServiceSuggestionResponse
+func ConvertServiceSuggestionsToResponse(suggestions []ServiceSuggestion) []ServiceSuggestionResponse {
+	result := make([]ServiceSuggestionResponse, len(suggestions))
+	for i, suggestion := range suggestions {
+		result[i] = ServiceSuggestionResponse{
+			Service:     ConvertServiceToResponse(suggestion.Service),
+			MatchedName: suggestion.MatchedName,
+			Similarity:  suggestion.Similarity,
+		}
+	}
+	return result
+}
+
+// SubStatsResponse represents statistics of subscriptions within a month or a period.
+// Prices are prices in effect per month of billing in the target currency, price and tenure
+// fields are omitted without active subscriptions
+type SubStatsResponse struct {
+	ActiveSubscribers   int      `json:"active_subscribers" example:"42"`
+	ActiveSubscriptions int      `json:"active_subscriptions" example:"45"`
+	NewSubscriptions    int      `json:"new_subscriptions" example:"5"`
+	EndedSubscriptions  int      `json:"ended_subscriptions" example:"3"`
+	AveragePrice        *Money   `json:"average_price,omitempty"`
+	MedianPrice         *Money   `json:"median_price,omitempty"`
+	AverageTenureMonths *float64 `json:"average_tenure_months,omitempty" example:"7.25"`
+}
+
+// MonthStatsResponse represents statistics of subscriptions within a month in YYYY-MM or MM-YYYY format
+type MonthStatsResponse struct {
+	Month string `json:"month" example:"2025-03"`
+	SubStatsResponse
+}
+
+// ServiceStatsResponse represents statistics of subscriptions to a catalog service over the period and per month
+type ServiceStatsResponse struct {
+	Service  *ServiceResponse     `json:"service"`
+	Currency string               `json:"currency" example:"RUB"`
+	Period   SubStatsResponse     `json:"period"`
+	Months   []MonthStatsResponse `json:"months"`
+}
+
+// ServiceStatsRowResponse represents statistics of subscriptions to a single service over the period
+type ServiceStatsRowResponse struct {
+	ServiceID   uuid.UUID `json:"service_id" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+	ServiceName string    `json:"service_name" example:"Netflix"`
+	SubStatsResponse
+}
+
+// StatsSummaryResponse represents statistics of subscriptions to all services over the period and per service
+type StatsSummaryResponse struct {
+	Currency string                    `json:"currency" example:"RUB"`
+	Total    SubStatsResponse          `json:"total"`
+	Services []ServiceStatsRowResponse `json:"services"`
+}
+
+// ConvertSubStatsToResponse преобразует доменную SubStats в SubStatsResponse
+func ConvertSubStatsToResponse(stats SubStats) SubStatsResponse {
+	return SubStatsResponse{
+		ActiveSubscribers:   stats.ActiveSubscribers,
+		ActiveSubscriptions: stats.ActiveSubscriptions,
+		NewSubscriptions:    stats.NewSubscriptions,
+		EndedSubscriptions:  stats.EndedSubscriptions,
+		AveragePrice:        stats.AveragePrice,
+		MedianPrice:         stats.MedianPrice,
+		AverageTenureMonths: stats.AverageTenureMonths,
+	}
+}
+
+// ConvertServiceStatsToResponse преобразует доменную ServiceStats в ServiceStatsResponse с месяцами в формате format
+func ConvertServiceStatsToResponse(stats *ServiceStats, format utils.DateFormat) *ServiceStatsResponse {
+	response := &ServiceStatsResponse{
+		Service:  ConvertServiceToResponse(stats.Service),
+		Currency: stats.Currency,
+		Period:   ConvertSubStatsToResponse(stats.Period),
+		Months:   make([]MonthStatsResponse, len(stats.Months)),
+	}
+
+	for i, month := range stats.Months {
+		response.Months[i] = MonthStatsResponse{
+			Month:            utils.FormatMonth(month.Month, format),
+			SubStatsResponse: ConvertSubStatsToResponse(month.SubStats),
+		}
+	}
+
+	return response
+}
+
+// ConvertStatsSummaryToResponse преобразует доменную StatsSummary в StatsSummaryResponse
+func ConvertStatsSummaryToResponse(summary *StatsSummary) *StatsSummaryResponse {
+	response := &StatsSummaryResponse{
+		Currency: summary.Currency,
+		Total:    ConvertSubStatsToResponse(summary.Total),
+		Services: make([]ServiceStatsRowResponse, len(summary.Services)),
+	}
+
+	for i, service := range summary.Services {
+		response.Services[i] = ServiceStatsRowResponse{
+			ServiceID:        service.ServiceID,
+			ServiceName:      service.ServiceName,
+			SubStatsResponse: ConvertSubStatsToResponse(service.SubStats),
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// Виды доменных ошибок, которые возвращают репозиторий и сервис
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")
+	ErrValidation  = errors.New("validation failed")
+	ErrUnavailable = errors.New("service unavailable")
+
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// Error represents domain error of a specific kind with a message safe to show to clients
+type Error struct {
+	Kind    error
+	Message string
+	Err     error
+}
+
+func NewError(kind error, message string, err error) *Error {
+	return &Error{
+		Kind:    kind,
+		Message: message,
+		Err:     err,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+
+	return e.Message
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+
+	return []error{e.Kind}
+}
+
+// FieldError describes invalid value of a single field
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ValidationError represents validation failure with per-field errors
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func NewValidationError(fields ...FieldError) *ValidationError {
+	return &ValidationError{
+		Fields: fields,
+	}
+}
+
+// Add добавляет ошибку поля
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{
+		Field:   field,
+		Message: message,
+	})
+}
+
+// OrNil возвращает nil, если ошибок полей нет
+func (e *ValidationError) OrNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	msg := ErrValidation.Error()
+	for i, field := range e.Fields {
+		if i == 0 {
+			msg += ": "
+		} else {
+			msg += "; "
+		}
+		msg += field.Field + " " + field.Message
+	}
+
+	return msg
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// OverlapError reports that subscription overlaps with subscription ConflictingID
+// of the same user and service
+type OverlapError struct {
+	ConflictingID uuid.UUID
+}
+
+func NewOverlapError(conflictingID uuid.UUID) *OverlapError {
+	return &OverlapError{
+		ConflictingID: conflictingID,
+	}
+}
+
+func (e *OverlapError) Error() string {
+	return "subscription overlaps with subscription " + e.ConflictingID.String()
+}
+
+func (e *OverlapError) Is(target error) bool {
+	return target == ErrConflict
+}
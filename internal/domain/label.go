@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MaxTagLength = 64
+	MaxTags      = 20
+)
+
+// NormalizeCategory приводит категорию к нижнему регистру с одиночными пробелами между словами
+func NormalizeCategory(category *string) *string {
+	if category == nil {
+		return nil
+	}
+
+	normalized := strings.ToLower(strings.Join(strings.Fields(*category), " "))
+	return &normalized
+}
+
+// NormalizeTags приводит метки к нижнему регистру без пробелов по краям, убирает повторы и сортирует их
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+// validateCategory добавляет ошибку поля категории
+func validateCategory(verr *ValidationError, field string, category *string) {
+	if category == nil {
+		return
+	}
+
+	switch {
+	case *category == "":
+		verr.Add(field, "must not be empty")
+	case utf8.RuneCountInString(*category) > MaxCategoryLength:
+		verr.Add(field, fmt.Sprintf("must be at most %d characters", MaxCategoryLength))
+	}
+}
+
+// validateTags добавляет ошибки полей меток, метки не могут содержать пробелы и запятые,
+// чтобы их можно было перечислить через запятую в параметрах запроса
+func validateTags(verr *ValidationError, tags []string) {
+	if len(tags) > MaxTags {
+		verr.Add("tags", fmt.Sprintf("must contain at most %d tags", MaxTags))
+	}
+
+	for i, tag := range tags {
+		field := fmt.Sprintf("tags[%d]", i)
+
+		switch {
+		case tag == "":
+			verr.Add(field, "must not be empty")
+		case utf8.RuneCountInString(tag) > MaxTagLength:
+			verr.Add(field, fmt.Sprintf("must be at most %d characters", MaxTagLength))
+		case strings.ContainsFunc(tag, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }):
+			verr.Add(field, "must not contain spaces or commas")
+		}
+	}
+}
+
+// SetLabels задаёт категорию и метки подписки
+func (s *Sub) SetLabels(category *string, tags []string) error {
+	s.Category = NormalizeCategory(category)
+	s.Tags = NormalizeTags(tags)
+
+	verr := NewValidationError()
+	validateCategory(verr, "category", s.Category)
+	validateTags(verr, s.Tags)
+
+	return verr.OrNil()
+}
@@ -0,0 +1,221 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultMinorUnits is the number of digits after the decimal point for most currencies
+const DefaultMinorUnits = 2
+
+// minorUnits содержит число знаков после запятой для валют, у которых оно отличается от DefaultMinorUnits
+var minorUnits = map[string]int{
+	"BHD": 3,
+	"CLP": 0,
+	"IQD": 3,
+	"ISK": 0,
+	"JOD": 3,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"LYD": 3,
+	"OMR": 3,
+	"TND": 3,
+	"VND": 0,
+}
+
+// MinorUnits возвращает число знаков после запятой в сумме валюты по ISO-4217
+func MinorUnits(currency string) int {
+	if units, ok := minorUnits[currency]; ok {
+		return units
+	}
+	return DefaultMinorUnits
+}
+
+// MinorUnitsExceptions возвращает валюты, число знаков которых отличается от DefaultMinorUnits
+func MinorUnitsExceptions() map[string]int {
+	result := make(map[string]int, len(minorUnits))
+	for currency, units := range minorUnits {
+		result[currency] = units
+	}
+	return result
+}
+
+// Money represents amount of money in minor units of currency (kopecks, cents).
+// Amounts are rounded half away from zero both when prorated and when converted
+// between currencies, each charge is rounded separately before summing.
+type Money struct {
+	Amount   int64  `json:"amount" swaggertype:"string" example:"999.90"`
+	Currency string `json:"currency" example:"RUB"`
+}
+
+// NewMoney создаёт сумму из количества минимальных единиц валюты
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney разбирает десятичную сумму в основных единицах валюты, например "999.90".
+// Знаков после запятой не может быть больше, чем минимальных единиц у валюты
+func ParseMoney(amount, currency string) (Money, error) {
+	amount = strings.TrimSpace(amount)
+	if amount == "" {
+		return Money{}, errors.New("must not be empty")
+	}
+
+	negative := false
+	switch amount[0] {
+	case '-':
+		negative = true
+		amount = amount[1:]
+	case '+':
+		amount = amount[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(amount, ".")
+	if whole == "" || !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, errors.New("must be a decimal amount")
+	}
+
+	units := MinorUnits(currency)
+	if len(fraction) > units {
+		return Money{}, fmt.Errorf("must have at most %d decimal places", units)
+	}
+	fraction += strings.Repeat("0", units-len(fraction))
+
+	minor, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return Money{}, errors.New("is out of range")
+	}
+
+	if negative {
+		minor = -minor
+	}
+
+	return NewMoney(minor, currency), nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Decimal возвращает сумму в основных единицах валюты, например "999.90"
+func (m Money) Decimal() string {
+	units := MinorUnits(m.Currency)
+
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+	if units == 0 {
+		return sign + digits
+	}
+	if len(digits) <= units {
+		digits = strings.Repeat("0", units-len(digits)+1) + digits
+	}
+
+	return sign + digits[:len(digits)-units] + "." + digits[len(digits)-units:]
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// Add складывает суммы одной валюты
+func (m Money) Add(other Money) Money {
+	return NewMoney(m.Amount+other.Amount, m.Currency)
+}
+
+// Mul умножает сумму на дробь num/den с округлением половины от нуля
+func (m Money) Mul(num, den int64) Money {
+	return NewMoney(divRound(m.Amount*num, den), m.Currency)
+}
+
+// WithCurrency переводит сумму в валюту с другим числом минимальных единиц без изменения её значения,
+// лишние знаки округляются половиной от нуля
+func (m Money) WithCurrency(currency string) Money {
+	diff := MinorUnits(currency) - MinorUnits(m.Currency)
+	scale := int64(math.Pow10(abs(diff)))
+
+	if diff >= 0 {
+		return NewMoney(m.Amount*scale, currency)
+	}
+	return NewMoney(divRound(m.Amount, scale), currency)
+}
+
+// divRound делит a на b с округлением половины от нуля
+func divRound(a, b int64) int64 {
+	if b < 0 {
+		a, b = -a, -b
+	}
+
+	q, r := a/b, a%b
+	if 2*abs64(r) >= b {
+		if a < 0 {
+			q--
+		} else {
+			q++
+		}
+	}
+
+	return q
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func abs64(x int64) int64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+type moneyJSON struct {
+	Amount   json.Number `json:"amount"`
+	Currency string      `json:"currency"`
+}
+
+// MarshalJSON сериализует сумму как {"amount": "999.90", "currency": "RUB"}
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{
+		Amount:   m.Decimal(),
+		Currency: m.Currency,
+	})
+}
+
+// UnmarshalJSON разбирает сумму вида {"amount": "999.90", "currency": "RUB"}, amount может быть числом,
+// без currency сумма считается в BaseCurrency
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw moneyJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	money, err := ParseMoney(raw.Amount.String(), NormalizeCurrency(raw.Currency))
+	if err != nil {
+		return fmt.Errorf("amount %s", err)
+	}
+
+	*m = money
+	return nil
+}
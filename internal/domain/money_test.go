@@ -0,0 +1,76 @@
+package domain
+
+import "testing"
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{name: "whole", amount: "999", currency: "RUB", want: 99900},
+		{name: "two places", amount: "999.90", currency: "RUB", want: 99990},
+		{name: "one place is padded", amount: "999.9", currency: "USD", want: 99990},
+		{name: "trailing point", amount: "5.", currency: "RUB", want: 500},
+		{name: "spaces", amount: " 12.34 ", currency: "EUR", want: 1234},
+		{name: "plus sign", amount: "+1.50", currency: "RUB", want: 150},
+		{name: "negative", amount: "-1.50", currency: "RUB", want: -150},
+		{name: "too many places", amount: "1.999", currency: "RUB", wantErr: true},
+		{name: "zero exponent", amount: "1500", currency: "JPY", want: 1500},
+		{name: "zero exponent with zero fraction is rejected", amount: "1500.0", currency: "JPY", wantErr: true},
+		{name: "zero exponent with fraction", amount: "1500.5", currency: "KRW", wantErr: true},
+		{name: "three places", amount: "1.234", currency: "KWD", want: 1234},
+		{name: "three places padded", amount: "1.2", currency: "BHD", want: 1200},
+		{name: "no whole part", amount: ".5", currency: "RUB", wantErr: true},
+		{name: "letters", amount: "12a", currency: "RUB", wantErr: true},
+		{name: "exponent notation", amount: "1e3", currency: "RUB", wantErr: true},
+		{name: "empty", amount: "", currency: "RUB", wantErr: true},
+		{name: "out of range", amount: "99999999999999999999", currency: "RUB", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMoney(tt.amount, tt.currency)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMoney(%q, %s) error = %v, wantErr %v", tt.amount, tt.currency, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got.Amount != tt.want || got.Currency != tt.currency {
+				t.Errorf("ParseMoney(%q, %s) = %+v, want %d %s", tt.amount, tt.currency, got, tt.want, tt.currency)
+			}
+		})
+	}
+}
+
+func TestMoneyDecimal(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{money: NewMoney(99990, "RUB"), want: "999.90"},
+		{money: NewMoney(5, "RUB"), want: "0.05"},
+		{money: NewMoney(0, "USD"), want: "0.00"},
+		{money: NewMoney(-150, "RUB"), want: "-1.50"},
+		{money: NewMoney(1500, "JPY"), want: "1500"},
+		{money: NewMoney(1234, "KWD"), want: "1.234"},
+		{money: NewMoney(7, "BHD"), want: "0.007"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want+" "+tt.money.Currency, func(t *testing.T) {
+			if got := tt.money.Decimal(); got != tt.want {
+				t.Errorf("Decimal() = %q, want %q", got, tt.want)
+			}
+
+			parsed, err := ParseMoney(tt.want, tt.money.Currency)
+			if err != nil || parsed != tt.money {
+				t.Errorf("ParseMoney(%q) = %+v, %v, want %+v", tt.want, parsed, err, tt.money)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// ServiceSuggestSimilarity is the minimal trigram similarity (pg_trgm, from 0 to 1) of normalized names
+// for a catalog service to be suggested. Subscriptions are matched to the catalog by exact names only
+const ServiceSuggestSimilarity = 0.3
+
+// serviceNameAbbreviations раскрывает распространённые сокращения слов в именах сервисов.
+// Другие имена конкретного сервиса задаются его псевдонимами в каталоге
+var serviceNameAbbreviations = map[string]string{
+	"fam":  "family",
+	"ind":  "individual",
+	"prem": "premium",
+	"std":  "standard",
+	"yt":   "youtube",
+}
+
+// NormalizeServiceName приводит имя сервиса к виду, по которому сопоставляются имена и псевдонимы:
+// нижний регистр без диакритики латинских букв и совместимых форм символов, знаки препинания
+// заменены пробелами, слова разделены одним пробелом, сокращения раскрыты. Имя только из знаков
+// препинания лишь приводится к нижнему регистру
+func NormalizeServiceName(name string) string {
+	var (
+		b    strings.Builder
+		base rune
+	)
+	for _, r := range norm.NFKD.String(strings.ToLower(name)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Диакритика латинских букв после разложения NFKD отбрасывается: "é" становится "e".
+			// В других алфавитах знак различает буквы ("й" и "и"), поэтому остаётся
+			if !unicode.Is(unicode.Latin, base) {
+				b.WriteRune(r)
+			}
+		case unicode.IsLetter(r), unicode.IsDigit(r):
+			b.WriteRune(r)
+			base = r
+		default:
+			b.WriteRune(' ')
+			base = 0
+		}
+	}
+
+	words := strings.Fields(norm.NFC.String(b.String()))
+	if len(words) == 0 {
+		return strings.ToLower(strings.Join(strings.Fields(name), " "))
+	}
+
+	for i, word := range words {
+		if full, ok := serviceNameAbbreviations[word]; ok {
+			words[i] = full
+		}
+	}
+
+	return strings.Join(words, " ")
+}
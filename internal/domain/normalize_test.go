@@ -0,0 +1,36 @@
+package domain
+
+import "testing"
+
+func TestNormalizeServiceName(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "lowercase and spaces", in: "  Netflix   Premium ", want: "netflix premium"},
+		{name: "diacritics", in: "Déezer Musíc", want: "deezer music"},
+		{name: "full-width letters", in: "Ｎｅｔｆｌｉｘ", want: "netflix"},
+		{name: "ligature", in: "ﬁlm club", want: "film club"},
+		{name: "punctuation", in: "Disney+ / Hulu", want: "disney hulu"},
+		{name: "hyphen and dots", in: "Apple.TV-Plus", want: "apple tv plus"},
+		{name: "abbreviations", in: "YT Prem Fam", want: "youtube premium family"},
+		{name: "abbreviation inside word", in: "Premier Standard", want: "premier standard"},
+		{name: "std and ind", in: "Spotify Std Ind", want: "spotify standard individual"},
+		{name: "digits", in: "Kinopoisk HD 2.0", want: "kinopoisk hd 2 0"},
+		{name: "cyrillic", in: "Яндекс Плюс", want: "яндекс плюс"},
+		{name: "cyrillic short i", in: "Мой Сервис", want: "мой сервис"},
+		{name: "cyrillic yo", in: "Ёлка ТВ", want: "ёлка тв"},
+		{name: "decomposed latin", in: "Cafe\u0301 Music", want: "cafe music"},
+		{name: "only punctuation", in: " +++  ", want: "+++"},
+		{name: "empty", in: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeServiceName(tt.in); got != tt.want {
+				t.Errorf("NormalizeServiceName(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
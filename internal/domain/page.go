@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 500
+)
+
+// SortField represents field subscriptions listing can be sorted by
+type SortField string
+
+const (
+	SortByStartDate   SortField = "start_date"
+	SortByPrice       SortField = "price"
+	SortByServiceName SortField = "service_name"
+)
+
+func (f SortField) Valid() bool {
+	switch f {
+	case SortByStartDate, SortByPrice, SortByServiceName:
+		return true
+	default:
+		return false
+	}
+}
+
+// ListSubsParams represents pagination, sorting and filtering parameters of subscriptions listing
+type ListSubsParams struct {
+	UserID      *uuid.UUID
+	ServiceName *string // имя или псевдоним сервиса из каталога
+	ServiceID   *uuid.UUID
+	Category    *string
+	// Tags оставляет подписки, у которых есть все перечисленные метки
+	Tags     []string
+	MinPrice *float64 // в основных единицах валюты подписки
+	MaxPrice *float64
+	// ActiveFrom и ActiveTo ограничивают период, в котором подписка должна быть активна хотя бы один день
+	ActiveFrom *time.Time
+	ActiveTo   *time.Time
+	Ended      *bool
+	// Statuses оставляет подписки, состояние которых на дату StatusAt входит в список
+	Statuses []SubStatus
+	StatusAt time.Time
+	// IncludeDeleted включает в выборку подписки, помеченные удалёнными
+	IncludeDeleted bool
+	Sort           SortField
+	Desc           bool
+	Limit          int
+	Cursor         *Cursor
+}
+
+// SubPage represents single page of subscriptions listing
+type SubPage struct {
+	Subs       []*Sub
+	NextCursor *Cursor
+}
+
+// Cursor points to the last subscription of a page in keyset pagination
+type Cursor struct {
+	Sort  SortField `json:"s"`
+	Desc  bool      `json:"d,omitempty"`
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// Encode возвращает непрозрачное строковое представление курсора
+func (c *Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("malformed cursor")
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || !cursor.Sort.Valid() {
+		return nil, errors.New("malformed cursor")
+	}
+
+	return &cursor, nil
+}
@@ -0,0 +1,46 @@
+package domain
+
+import "encoding/json"
+
+// Nullable represents field of JSON Merge Patch (RFC 7396) request:
+// omitted field is not Set, explicit null is Set and Null
+type Nullable[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+// NewNullable возвращает заданное значение поля
+func NewNullable[T any](value T) Nullable[T] {
+	return Nullable[T]{
+		Set:   true,
+		Value: value,
+	}
+}
+
+// HasValue сообщает, что поле передано и не равно null
+func (n Nullable[T]) HasValue() bool {
+	return n.Set && !n.Null
+}
+
+func (n *Nullable[T]) UnmarshalJSON(data []byte) error {
+	n.Set = true
+
+	if string(data) == "null" {
+		var zero T
+		n.Null = true
+		n.Value = zero
+		return nil
+	}
+
+	n.Null = false
+	return json.Unmarshal(data, &n.Value)
+}
+
+func (n Nullable[T]) MarshalJSON() ([]byte, error) {
+	if !n.HasValue() {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(n.Value)
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Pause represents interval subscription is paused in, To is the last paused day, nil until resumed
+type Pause struct {
+	From time.Time
+	To   *time.Time
+}
+
+// covers сообщает, что подписка на паузе в указанную дату
+func (p Pause) covers(date time.Time) bool {
+	return !date.Before(p.From) && (p.To == nil || !date.After(*p.To))
+}
+
+// overlaps сообщает, что пауза пересекается с интервалом [from, to], to равный nil означает бесконечность
+func (p Pause) overlaps(from time.Time, to *time.Time) bool {
+	return (to == nil || !p.From.After(*to)) && (p.To == nil || !from.After(*p.To))
+}
+
+// Pause ставит подписку на паузу с from по to включительно, to равный nil означает паузу до возобновления
+func (s *Sub) Pause(from time.Time, to *time.Time) error {
+	verr := NewValidationError()
+
+	if from.Before(s.StartDate) {
+		verr.Add("from", "must not be before start_date")
+	}
+	if s.EndDate != nil && from.After(*s.EndDate) {
+		verr.Add("from", "must not be after end_date")
+	}
+	if to != nil && to.Before(from) {
+		verr.Add("to", "must not be before from")
+	}
+
+	if err := verr.OrNil(); err != nil {
+		return err
+	}
+
+	for _, pause := range s.Pauses {
+		if pause.overlaps(from, to) {
+			return NewError(ErrConflict, "subscription is already paused in this period", fmt.Errorf("subscription %s is paused from %s", s.ID, pause.From))
+		}
+	}
+
+	s.Pauses = append(s.Pauses, Pause{From: from, To: to})
+	sort.Slice(s.Pauses, func(i, j int) bool {
+		return s.Pauses[i].From.Before(s.Pauses[j].From)
+	})
+
+	return nil
+}
+
+// Resume возобновляет подписку с даты at: пауза, действующая в этот день, заканчивается накануне at.
+// Если такой нет, отменяется ближайшая пауза, которая к этому дню ещё не началась
+func (s *Sub) Resume(at time.Time) error {
+	for i, pause := range s.Pauses {
+		if pause.To != nil && pause.To.Before(at) {
+			continue
+		}
+
+		to := at.AddDate(0, 0, -1)
+		if to.Before(pause.From) {
+			s.Pauses = append(s.Pauses[:i], s.Pauses[i+1:]...)
+			return nil
+		}
+
+		s.Pauses[i].To = &to
+		return nil
+	}
+
+	return NewError(ErrConflict, "subscription is not paused", fmt.Errorf("subscription %s is not paused at %s", s.ID, at))
+}
+
+// PausedAt сообщает, что подписка на паузе в указанную дату
+func (s *Sub) PausedAt(date time.Time) bool {
+	for _, pause := range s.Pauses {
+		if pause.covers(date) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/utils"
+)
+
+// parsePauses разбирает паузы вида "2025-02-01..2025-02-10", пауза без даты окончания записывается как "2025-03-01.."
+func parsePauses(t *testing.T, pauses ...string) []Pause {
+	t.Helper()
+
+	result := make([]Pause, 0, len(pauses))
+	for _, p := range pauses {
+		from, to, _ := strings.Cut(p, "..")
+
+		var pause Pause
+		var err error
+		if pause.From, err = utils.ParseDate(from); err != nil {
+			t.Fatalf("invalid pause %q: %v", p, err)
+		}
+		if to != "" {
+			date, err := utils.ParseDate(to)
+			if err != nil {
+				t.Fatalf("invalid pause %q: %v", p, err)
+			}
+			pause.To = &date
+		}
+		result = append(result, pause)
+	}
+
+	return result
+}
+
+// pauseStrings записывает паузы в виде, который принимает parsePauses
+func pauseStrings(pauses []Pause) []string {
+	result := make([]string, len(pauses))
+	for i, pause := range pauses {
+		result[i] = utils.ToDateString(pause.From) + ".."
+		if pause.To != nil {
+			result[i] += utils.ToDateString(*pause.To)
+		}
+	}
+	return result
+}
+
+func pausedSub(t *testing.T, pauses ...string) *Sub {
+	return &Sub{
+		ID:        uuid.New(),
+		StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Pauses:    parsePauses(t, pauses...),
+	}
+}
+
+func TestSubPause(t *testing.T) {
+	tests := []struct {
+		name    string
+		pauses  []string
+		endDate string
+		pause   string
+		want    []string
+		wantErr error
+	}{
+		{name: "closed pause", pause: "2025-02-01..2025-02-10", want: []string{"2025-02-01..2025-02-10"}},
+		{name: "open-ended pause", pause: "2025-03-01..", want: []string{"2025-03-01.."}},
+		{name: "single day", pause: "2025-02-01..2025-02-01", want: []string{"2025-02-01..2025-02-01"}},
+		{name: "on start date", pause: "2025-01-01..2025-01-05", want: []string{"2025-01-01..2025-01-05"}},
+		{name: "before start date", pause: "2024-12-31..2025-01-05", wantErr: ErrValidation},
+		{name: "after end date", endDate: "2025-06-30", pause: "2025-07-01..", wantErr: ErrValidation},
+		{name: "to before from", pause: "2025-02-10..2025-02-09", wantErr: ErrValidation},
+		{
+			name:   "right after closed pause",
+			pauses: []string{"2025-02-01..2025-02-10"},
+			pause:  "2025-02-11..",
+			want:   []string{"2025-02-01..2025-02-10", "2025-02-11.."},
+		},
+		{
+			name:   "kept ordered by from",
+			pauses: []string{"2025-03-01.."},
+			pause:  "2025-02-01..2025-02-28",
+			want:   []string{"2025-02-01..2025-02-28", "2025-03-01.."},
+		},
+		{
+			name:    "sharing a day with closed pause",
+			pauses:  []string{"2025-02-01..2025-02-10"},
+			pause:   "2025-02-10..2025-02-20",
+			wantErr: ErrConflict,
+		},
+		{
+			name:    "open-ended over closed pause",
+			pauses:  []string{"2025-02-01..2025-02-10"},
+			pause:   "2025-01-15..",
+			wantErr: ErrConflict,
+		},
+		{
+			name:    "after start of open-ended pause",
+			pauses:  []string{"2025-03-01.."},
+			pause:   "2025-04-01..2025-04-05",
+			wantErr: ErrConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := pausedSub(t, tt.pauses...)
+			if tt.endDate != "" {
+				endDate, _ := utils.ParseDate(tt.endDate)
+				sub.EndDate = &endDate
+			}
+
+			pause := parsePauses(t, tt.pause)[0]
+			err := sub.Pause(pause.From, pause.To)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Pause(%s) error = %v, want %v", tt.pause, err, tt.wantErr)
+			}
+
+			want := tt.want
+			if tt.wantErr != nil {
+				want = tt.pauses
+			}
+			if got := pauseStrings(sub.Pauses); strings.Join(got, " ") != strings.Join(want, " ") {
+				t.Errorf("pauses = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestSubResume(t *testing.T) {
+	tests := []struct {
+		name    string
+		pauses  []string
+		at      string
+		want    []string
+		wantErr error
+	}{
+		{name: "open-ended pause ends the day before", pauses: []string{"2025-03-01.."}, at: "2025-03-10", want: []string{"2025-03-01..2025-03-09"}},
+		{name: "closed pause is shortened", pauses: []string{"2025-03-01..2025-03-31"}, at: "2025-03-10", want: []string{"2025-03-01..2025-03-09"}},
+		{name: "resume on last paused day", pauses: []string{"2025-03-01..2025-03-31"}, at: "2025-03-31", want: []string{"2025-03-01..2025-03-30"}},
+		{name: "resume on first paused day cancels it", pauses: []string{"2025-03-01..2025-03-31"}, at: "2025-03-01"},
+		{name: "future open-ended pause is cancelled", pauses: []string{"2025-03-01.."}, at: "2025-02-20"},
+		{name: "future closed pause is cancelled", pauses: []string{"2025-03-01..2025-03-31"}, at: "2025-02-20"},
+		{
+			name:   "only the nearest future pause is cancelled",
+			pauses: []string{"2025-02-01..2025-02-10", "2025-03-01..2025-03-31", "2025-05-01.."},
+			at:     "2025-02-20",
+			want:   []string{"2025-02-01..2025-02-10", "2025-05-01.."},
+		},
+		{
+			name:   "only the covering pause changes",
+			pauses: []string{"2025-02-01..2025-02-10", "2025-03-01.."},
+			at:     "2025-04-01",
+			want:   []string{"2025-02-01..2025-02-10", "2025-03-01..2025-03-31"},
+		},
+		{name: "after the last pause", pauses: []string{"2025-02-01..2025-02-10"}, at: "2025-02-11", wantErr: ErrConflict},
+		{name: "no pauses", at: "2025-02-11", wantErr: ErrConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := pausedSub(t, tt.pauses...)
+			at, _ := utils.ParseDate(tt.at)
+
+			err := sub.Resume(at)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Resume(%s) error = %v, want %v", tt.at, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resume(%s) error = %v", tt.at, err)
+			}
+
+			if got := pauseStrings(sub.Pauses); strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("pauses = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubPausedAt(t *testing.T) {
+	sub := pausedSub(t, "2025-02-01..2025-02-10", "2025-03-01..")
+
+	paused := map[string]bool{
+		"2025-01-31": false,
+		"2025-02-01": true,
+		"2025-02-10": true,
+		"2025-02-11": false,
+		"2025-02-28": false,
+		"2025-03-01": true,
+		"2030-01-01": true,
+	}
+
+	for date, want := range paused {
+		at, _ := utils.ParseDate(date)
+		if got := sub.PausedAt(at); got != want {
+			t.Errorf("PausedAt(%s) = %v, want %v", date, got, want)
+		}
+	}
+}
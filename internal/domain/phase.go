@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/maYkiss56/subscription-aggregation-service/internal/utils"
+)
+
+// PhaseKind represents kind of introductory price phase
+type PhaseKind string
+
+const (
+	PhaseTrial PhaseKind = "trial"
+	PhasePromo PhaseKind = "promo"
+)
+
+func (k PhaseKind) Valid() bool {
+	switch k {
+	case PhaseTrial, PhasePromo:
+		return true
+	default:
+		return false
+	}
+}
+
+// PricePhase represents price of subscription for the given number of months.
+// Phases follow each other from start date of subscription, after the last one
+// the regular price of subscription is charged
+type PricePhase struct {
+	Kind   PhaseKind
+	Price  Money
+	Months int
+}
+
+// NewPricePhases разбирает фазы цены из запроса, цены задаются в основных единицах currency
+func NewPricePhases(reqs []PricePhaseRequest, currency string) ([]PricePhase, *ValidationError) {
+	verr := NewValidationError()
+
+	phases := make([]PricePhase, len(reqs))
+	for i, req := range reqs {
+		price, err := ParseMoney(req.Price.String(), currency)
+		if err != nil {
+			verr.Add(fmt.Sprintf("phases[%d].price", i), err.Error())
+		}
+
+		phases[i] = PricePhase{
+			Kind:   req.Kind,
+			Price:  price,
+			Months: req.Months,
+		}
+	}
+
+	if len(verr.Fields) > 0 {
+		return nil, verr
+	}
+
+	return phases, nil
+}
+
+// validatePhases добавляет ошибки полей фаз цены
+func (s *Sub) validatePhases(verr *ValidationError) {
+	for i, phase := range s.Phases {
+		field := fmt.Sprintf("phases[%d]", i)
+
+		if !phase.Kind.Valid() {
+			verr.Add(field+".kind", "must be trial or promo")
+		}
+
+		switch {
+		case phase.Kind == PhaseTrial && phase.Price.Amount != 0:
+			verr.Add(field+".price", "must be zero for trial phase")
+		case phase.Price.Amount < 0:
+			verr.Add(field+".price", "must not be negative")
+		}
+
+		if phase.Months < 1 {
+			verr.Add(field+".months", "must be positive")
+		}
+	}
+}
+
+// PhaseStart возвращает дату начала фазы цены с индексом i, для i == len(Phases) это начало регулярной цены
+func (s *Sub) PhaseStart(i int) time.Time {
+	months := 0
+	for _, phase := range s.Phases[:i] {
+		months += phase.Months
+	}
+
+	return utils.AddMonths(s.StartDate, months)
+}
+
+// PriceAt возвращает цену подписки, действующую на дату
+func (s *Sub) PriceAt(date time.Time) Money {
+	for i, phase := range s.Phases {
+		if date.Before(s.PhaseStart(i + 1)) {
+			return phase.Price
+		}
+	}
+
+	return s.regularPriceAt(date)
+}
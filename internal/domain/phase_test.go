@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maYkiss56/subscription-aggregation-service/internal/utils"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// newPhasedSub создаёт подписку с 31 января 2025: месяц пробного периода, два месяца промо и регулярная цена
+func newPhasedSub(t *testing.T) *Sub {
+	t.Helper()
+
+	sub := newTestSub(t, date(2025, 1, 31))
+	sub.Phases = []PricePhase{
+		{Kind: PhaseTrial, Price: NewMoney(0, "RUB"), Months: 1},
+		{Kind: PhasePromo, Price: NewMoney(19900, "RUB"), Months: 2},
+	}
+	return sub
+}
+
+func TestSubPhaseStart(t *testing.T) {
+	sub := newPhasedSub(t)
+
+	tests := []struct {
+		phase int
+		want  time.Time
+	}{
+		{phase: 0, want: date(2025, 1, 31)},
+		{phase: 1, want: date(2025, 2, 28)},
+		{phase: 2, want: date(2025, 4, 30)},
+	}
+
+	for _, tt := range tests {
+		if got := sub.PhaseStart(tt.phase); !got.Equal(tt.want) {
+			t.Errorf("PhaseStart(%d) = %s, want %s", tt.phase, utils.ToDateString(got), utils.ToDateString(tt.want))
+		}
+	}
+}
+
+func TestSubPriceAt(t *testing.T) {
+	sub := newPhasedSub(t)
+	sub.SetPrice(NewMoney(59900, "RUB"), date(2025, 6, 1))
+
+	tests := []struct {
+		name string
+		date time.Time
+		want int64
+	}{
+		{name: "first day of trial", date: date(2025, 1, 31), want: 0},
+		{name: "last day of trial", date: date(2025, 2, 27), want: 0},
+		{name: "first day of promo at month end", date: date(2025, 2, 28), want: 19900},
+		{name: "last day of promo", date: date(2025, 4, 29), want: 19900},
+		{name: "first day of regular price", date: date(2025, 4, 30), want: 49900},
+		{name: "day before price change", date: date(2025, 5, 31), want: 49900},
+		{name: "price change", date: date(2025, 6, 1), want: 59900},
+		{name: "far after price change", date: date(2030, 1, 1), want: 59900},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sub.PriceAt(tt.date); got.Amount != tt.want {
+				t.Errorf("PriceAt(%s) = %d, want %d", utils.ToDateString(tt.date), got.Amount, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubPriceAtBackToBackPhases(t *testing.T) {
+	sub := newTestSub(t, date(2025, 3, 31))
+	sub.Phases = []PricePhase{
+		{Kind: PhasePromo, Price: NewMoney(9900, "RUB"), Months: 1},
+		{Kind: PhasePromo, Price: NewMoney(19900, "RUB"), Months: 1},
+		{Kind: PhasePromo, Price: NewMoney(29900, "RUB"), Months: 1},
+	}
+
+	tests := []struct {
+		date time.Time
+		want int64
+	}{
+		{date: date(2025, 4, 29), want: 9900},
+		{date: date(2025, 4, 30), want: 19900},
+		{date: date(2025, 5, 30), want: 19900},
+		{date: date(2025, 5, 31), want: 29900},
+		{date: date(2025, 6, 29), want: 29900},
+		{date: date(2025, 6, 30), want: 49900},
+	}
+
+	for _, tt := range tests {
+		if got := sub.PriceAt(tt.date); got.Amount != tt.want {
+			t.Errorf("PriceAt(%s) = %d, want %d", utils.ToDateString(tt.date), got.Amount, tt.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// PhaseRegular is the kind of price periods with regular price of subscription
+const PhaseRegular PhaseKind = "regular"
+
+// PriceChange represents regular price of subscription effective from date
+type PriceChange struct {
+	EffectiveFrom time.Time
+	Price         Money
+}
+
+// PricePeriod represents price of subscription in effect between dates, To is nil for the last open-ended period
+type PricePeriod struct {
+	Kind  PhaseKind
+	From  time.Time
+	To    *time.Time
+	Price Money
+}
+
+// SetPrice добавляет в историю регулярную цену, действующую с даты effectiveFrom,
+// цена на ту же дату заменяется. Price подписки становится регулярной ценой, действующей сегодня
+func (s *Sub) SetPrice(price Money, effectiveFrom time.Time) {
+	i := sort.Search(len(s.PriceHistory), func(i int) bool {
+		return !s.PriceHistory[i].EffectiveFrom.Before(effectiveFrom)
+	})
+
+	change := PriceChange{EffectiveFrom: effectiveFrom, Price: price}
+	switch {
+	case i < len(s.PriceHistory) && s.PriceHistory[i].EffectiveFrom.Equal(effectiveFrom):
+		s.PriceHistory[i] = change
+	default:
+		s.PriceHistory = append(s.PriceHistory, PriceChange{})
+		copy(s.PriceHistory[i+1:], s.PriceHistory[i:])
+		s.PriceHistory[i] = change
+	}
+
+	s.Price = s.regularPriceAt(Today())
+}
+
+// regularPriceAt возвращает регулярную цену на дату: последнюю цену истории, действующую на эту дату,
+// до первой записи истории действует её цена
+func (s *Sub) regularPriceAt(date time.Time) Money {
+	if len(s.PriceHistory) == 0 {
+		return s.Price
+	}
+
+	price := s.PriceHistory[0].Price
+	for _, change := range s.PriceHistory[1:] {
+		if change.EffectiveFrom.After(date) {
+			break
+		}
+		price = change.Price
+	}
+
+	return price
+}
+
+// PriceTimeline возвращает периоды действия фаз цены и регулярных цен подписки по порядку
+func (s *Sub) PriceTimeline() []PricePeriod {
+	var timeline []PricePeriod
+
+	add := func(kind PhaseKind, from, to time.Time, price Money) {
+		if s.EndDate != nil {
+			if from.After(*s.EndDate) {
+				return
+			}
+			if to.IsZero() || to.After(*s.EndDate) {
+				to = *s.EndDate
+			}
+		}
+
+		period := PricePeriod{Kind: kind, From: from, Price: price}
+		if !to.IsZero() {
+			if to.Before(from) {
+				return
+			}
+			period.To = &to
+		}
+
+		timeline = append(timeline, period)
+	}
+
+	for i, phase := range s.Phases {
+		add(phase.Kind, s.PhaseStart(i), s.PhaseStart(i+1).AddDate(0, 0, -1), phase.Price)
+	}
+
+	regularStart := s.PhaseStart(len(s.Phases))
+	if len(s.PriceHistory) == 0 {
+		add(PhaseRegular, regularStart, time.Time{}, s.Price)
+		return timeline
+	}
+
+	for i, change := range s.PriceHistory {
+		from := change.EffectiveFrom
+		if i == 0 || from.Before(regularStart) {
+			from = regularStart
+		}
+
+		var to time.Time
+		if i+1 < len(s.PriceHistory) {
+			to = s.PriceHistory[i+1].EffectiveFrom.AddDate(0, 0, -1)
+		}
+
+		add(PhaseRegular, from, to, change.Price)
+	}
+
+	return timeline
+}
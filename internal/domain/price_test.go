@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/maYkiss56/subscription-aggregation-service/internal/utils"
+)
+
+// timelineStrings записывает периоды цен как "kind from..to price", открытый период без даты окончания
+func timelineStrings(timeline []PricePeriod) []string {
+	result := make([]string, len(timeline))
+	for i, period := range timeline {
+		to := ""
+		if period.To != nil {
+			to = utils.ToDateString(*period.To)
+		}
+		result[i] = fmt.Sprintf("%s %s..%s %d", period.Kind, utils.ToDateString(period.From), to, period.Price.Amount)
+	}
+	return result
+}
+
+func TestSubPriceTimeline(t *testing.T) {
+	tests := []struct {
+		name    string
+		endDate string
+		changes map[string]int64
+		want    []string
+	}{
+		{
+			name: "phases and regular price",
+			want: []string{
+				"trial 2025-01-31..2025-02-27 0",
+				"promo 2025-02-28..2025-04-29 19900",
+				"regular 2025-04-30.. 49900",
+			},
+		},
+		{
+			name:    "price change after phases",
+			changes: map[string]int64{"2025-06-01": 59900},
+			want: []string{
+				"trial 2025-01-31..2025-02-27 0",
+				"promo 2025-02-28..2025-04-29 19900",
+				"regular 2025-04-30..2025-05-31 49900",
+				"regular 2025-06-01.. 59900",
+			},
+		},
+		{
+			name:    "price change during promo",
+			changes: map[string]int64{"2025-03-01": 59900},
+			want: []string{
+				"trial 2025-01-31..2025-02-27 0",
+				"promo 2025-02-28..2025-04-29 19900",
+				"regular 2025-04-30.. 59900",
+			},
+		},
+		{
+			name:    "ends during promo",
+			endDate: "2025-03-15",
+			want: []string{
+				"trial 2025-01-31..2025-02-27 0",
+				"promo 2025-02-28..2025-03-15 19900",
+			},
+		},
+		{
+			name:    "ends on last day of promo",
+			endDate: "2025-04-29",
+			want: []string{
+				"trial 2025-01-31..2025-02-27 0",
+				"promo 2025-02-28..2025-04-29 19900",
+			},
+		},
+		{
+			name:    "ends before price change",
+			endDate: "2025-05-15",
+			changes: map[string]int64{"2025-06-01": 59900},
+			want: []string{
+				"trial 2025-01-31..2025-02-27 0",
+				"promo 2025-02-28..2025-04-29 19900",
+				"regular 2025-04-30..2025-05-15 49900",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := newPhasedSub(t)
+			if tt.endDate != "" {
+				endDate, err := utils.ParseDate(tt.endDate)
+				if err != nil {
+					t.Fatal(err)
+				}
+				sub.EndDate = &endDate
+			}
+			for effectiveFrom, amount := range tt.changes {
+				date, err := utils.ParseDate(effectiveFrom)
+				if err != nil {
+					t.Fatal(err)
+				}
+				sub.SetPrice(NewMoney(amount, "RUB"), date)
+			}
+
+			if got := timelineStrings(sub.PriceTimeline()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PriceTimeline() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Цена с будущей даты попадает в историю, но не становится ценой подписки до этой даты
+func TestSubSetPrice(t *testing.T) {
+	today := Today()
+	sub := newTestSub(t, today.AddDate(0, -6, 0))
+
+	sub.SetPrice(NewMoney(59900, "RUB"), today.AddDate(0, 1, 0))
+	if sub.Price.Amount != 49900 {
+		t.Errorf("price after future change = %d, want 49900", sub.Price.Amount)
+	}
+
+	sub.SetPrice(NewMoney(54900, "RUB"), today)
+	if sub.Price.Amount != 54900 {
+		t.Errorf("price after change from today = %d, want 54900", sub.Price.Amount)
+	}
+
+	// Цена на ту же дату заменяется, а не добавляется
+	sub.SetPrice(NewMoney(64900, "RUB"), today.AddDate(0, 1, 0))
+	if len(sub.PriceHistory) != 3 {
+		t.Fatalf("price history has %d records, want 3", len(sub.PriceHistory))
+	}
+	if got := sub.PriceAt(today.AddDate(0, 1, 0)); got.Amount != 64900 {
+		t.Errorf("price from next month = %d, want 64900", got.Amount)
+	}
+	if sub.Price.Amount != 54900 {
+		t.Errorf("price = %d, want 54900", sub.Price.Amount)
+	}
+}
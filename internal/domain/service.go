@@ -0,0 +1,172 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Service represents catalog entry subscriptions refer to by ServiceID.
+// Names of subscriptions are matched to the service by its normalized name and aliases
+type Service struct {
+	ID           uuid.UUID
+	Name         string
+	Aliases      []string
+	Category     *string
+	Homepage     *string
+	DefaultPrice *Money // цена и валюта, предлагаемые для новых подписок на сервис
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+const (
+	MaxCategoryLength = 64
+	MaxHomepageLength = 2048
+)
+
+// ServiceSuggestion represents catalog service matching a search query by its name or alias MatchedName
+type ServiceSuggestion struct {
+	Service     *Service
+	MatchedName string
+	Similarity  float64
+}
+
+const (
+	DefaultSuggestLimit = 10
+	MaxSuggestLimit     = 50
+)
+
+// NewService создаёт запись каталога сервисов
+func NewService(name string, aliases []string, category, homepage *string, defaultPrice *Money) (*Service, error) {
+	service := &Service{
+		ID:           uuid.New(),
+		Name:         strings.TrimSpace(name),
+		Aliases:      trimAliases(aliases),
+		Category:     NormalizeCategory(category),
+		Homepage:     trimOptional(homepage),
+		DefaultPrice: defaultPrice,
+	}
+
+	if err := service.Validate(); err != nil {
+		return nil, err
+	}
+
+	return service, nil
+}
+
+// Validate проверяет инварианты сервиса и возвращает ошибки по полям
+func (s *Service) Validate() error {
+	verr := NewValidationError()
+
+	switch {
+	case s.Name == "":
+		verr.Add("name", "must not be empty")
+	case utf8.RuneCountInString(s.Name) > MaxServiceNameLength:
+		verr.Add("name", fmt.Sprintf("must be at most %d characters", MaxServiceNameLength))
+	}
+
+	names := map[string]bool{NormalizeServiceName(s.Name): true}
+	for i, alias := range s.Aliases {
+		field := fmt.Sprintf("aliases[%d]", i)
+		normalized := NormalizeServiceName(alias)
+
+		switch {
+		case alias == "":
+			verr.Add(field, "must not be empty")
+		case utf8.RuneCountInString(alias) > MaxServiceNameLength:
+			verr.Add(field, fmt.Sprintf("must be at most %d characters", MaxServiceNameLength))
+		case names[normalized]:
+			verr.Add(field, "must differ from name and other aliases")
+		}
+		names[normalized] = true
+	}
+
+	validateCategory(verr, "category", s.Category)
+
+	if s.Homepage != nil {
+		u, err := url.Parse(*s.Homepage)
+		switch {
+		case err != nil, u.Scheme != "http" && u.Scheme != "https", u.Host == "":
+			verr.Add("homepage", "must be http or https URL")
+		case len(*s.Homepage) > MaxHomepageLength:
+			verr.Add("homepage", fmt.Sprintf("must be at most %d characters", MaxHomepageLength))
+		}
+	}
+
+	if s.DefaultPrice != nil {
+		if s.DefaultPrice.Amount <= 0 {
+			verr.Add("default_price.amount", "must be positive")
+		}
+		if !ValidCurrency(s.DefaultPrice.Currency) {
+			verr.Add("default_price.currency", "must be ISO-4217 currency code")
+		}
+	}
+
+	return verr.OrNil()
+}
+
+// Apply применяет изменения из запроса на обновление к сервису по правилам JSON Merge Patch
+func (s *Service) Apply(req *UpdateServiceRequest) error {
+	verr := NewValidationError()
+
+	if req.Name.Set {
+		if req.Name.Null {
+			verr.Add("name", "must not be null")
+		}
+		s.Name = strings.TrimSpace(req.Name.Value)
+	}
+
+	// Псевдонимы заменяются целиком, null удаляет их
+	if req.Aliases.Set {
+		s.Aliases = trimAliases(req.Aliases.Value)
+	}
+
+	if req.Category.Set {
+		s.Category = nil
+		if req.Category.HasValue() {
+			s.Category = NormalizeCategory(&req.Category.Value)
+		}
+	}
+
+	if req.Homepage.Set {
+		s.Homepage = nil
+		if req.Homepage.HasValue() {
+			s.Homepage = trimOptional(&req.Homepage.Value)
+		}
+	}
+
+	if req.DefaultPrice.Set {
+		s.DefaultPrice = nil
+		if req.DefaultPrice.HasValue() {
+			price := req.DefaultPrice.Value
+			s.DefaultPrice = &price
+		}
+	}
+
+	if err := verr.OrNil(); err != nil {
+		return err
+	}
+
+	return s.Validate()
+}
+
+func trimAliases(aliases []string) []string {
+	result := make([]string, len(aliases))
+	for i, alias := range aliases {
+		result[i] = strings.TrimSpace(alias)
+	}
+	return result
+}
+
+func trimOptional(s *string) *string {
+	if s == nil {
+		return nil
+	}
+
+	trimmed := strings.TrimSpace(*s)
+	return &trimmed
+}
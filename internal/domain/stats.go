@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StatsFilter represents period and currency subscription statistics are computed for.
+// Dates are in YYYY-MM-DD format
+type StatsFilter struct {
+	StartPeriod    string
+	EndPeriod      string
+	TargetCurrency string
+	ServiceID      *uuid.UUID
+	ServiceName    *string
+}
+
+// SubStats represents statistics of subscriptions active within a month or a period.
+// Subscriptions paused for the whole month or period are not active. Prices are trial, promo
+// or regular prices in effect on the last active day, converted to a month of billing and to
+// the target currency. Average and median price and tenure are nil without active subscriptions
+type SubStats struct {
+	ActiveSubscribers   int // различные пользователи с активными подписками
+	ActiveSubscriptions int
+	NewSubscriptions    int // подписки, начавшиеся в месяце или периоде
+	EndedSubscriptions  int // подписки, закончившиеся в месяце или периоде
+	AveragePrice        *Money
+	MedianPrice         *Money
+	AverageTenureMonths *float64 // от start_date до последнего активного дня
+}
+
+// MonthStats represents statistics of subscriptions within a single month of the period
+type MonthStats struct {
+	Month time.Time
+	SubStats
+}
+
+// ServiceStats represents statistics of subscriptions to a catalog service over the period and per month
+type ServiceStats struct {
+	Service  *Service
+	Currency string
+	Period   SubStats
+	Months   []MonthStats
+}
+
+// ServiceStatsRow represents statistics of subscriptions to a single service in the summary
+type ServiceStatsRow struct {
+	ServiceID   uuid.UUID
+	ServiceName string
+	SubStats
+}
+
+// StatsSummary represents statistics of subscriptions to all services over the period and per service,
+// services are ordered by active subscribers
+type StatsSummary struct {
+	Currency string
+	Total    SubStats
+	Services []ServiceStatsRow
+}
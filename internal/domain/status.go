@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"time"
+)
+
+// SubStatus represents lifecycle state of subscription derived from its dates and pauses
+type SubStatus string
+
+const (
+	StatusScheduled  SubStatus = "scheduled"
+	StatusActive     SubStatus = "active"
+	StatusEndingSoon SubStatus = "ending_soon"
+	StatusEnded      SubStatus = "ended"
+	StatusPaused     SubStatus = "paused"
+)
+
+// EndingSoonDays is the number of days before end_date subscription is ending soon
+const EndingSoonDays = 30
+
+func (s SubStatus) Valid() bool {
+	switch s {
+	case StatusScheduled, StatusActive, StatusEndingSoon, StatusEnded, StatusPaused:
+		return true
+	default:
+		return false
+	}
+}
+
+// StatusAt возвращает состояние подписки на дату. Состояния проверяются по порядку:
+// ещё не началась, уже закончилась, на паузе, заканчивается в ближайшие EndingSoonDays дней
+func (s *Sub) StatusAt(date time.Time) SubStatus {
+	switch {
+	case s.StartDate.After(date):
+		return StatusScheduled
+	case s.EndDate != nil && s.EndDate.Before(date):
+		return StatusEnded
+	case s.PausedAt(date):
+		return StatusPaused
+	case s.EndDate != nil && s.EndDate.Before(date.AddDate(0, 0, EndingSoonDays)):
+		return StatusEndingSoon
+	default:
+		return StatusActive
+	}
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/maYkiss56/subscription-aggregation-service/internal/utils"
+)
+
+func TestSubStatusAt(t *testing.T) {
+	const at = "2025-06-15"
+
+	tests := []struct {
+		start, end string // пустая дата окончания означает бессрочную подписку
+		pauses     []string
+		want       SubStatus
+	}{
+		{start: "2025-06-16", want: StatusScheduled},
+		{start: at, want: StatusActive},
+		{start: "2025-01-01", want: StatusActive},
+		{start: "2025-01-01", end: "2025-06-14", want: StatusEnded},
+		{start: "2025-01-01", end: at, want: StatusEndingSoon},
+		{start: "2025-01-01", end: "2025-07-14", want: StatusEndingSoon}, // через EndingSoonDays-1 дней
+		{start: "2025-01-01", end: "2025-07-15", want: StatusActive},     // через EndingSoonDays дней
+		{start: "2025-01-01", pauses: []string{"2025-06-01.."}, want: StatusPaused},
+		{start: "2025-01-01", pauses: []string{"2025-06-01..2025-06-14"}, want: StatusActive},
+		{start: "2025-01-01", pauses: []string{"2025-06-01..2025-06-15"}, want: StatusPaused},
+		{start: "2025-01-01", pauses: []string{"2025-06-16.."}, want: StatusActive},
+		// Пауза важнее скорого окончания, а окончание и будущее начало важнее паузы
+		{start: "2025-01-01", end: "2025-06-20", pauses: []string{"2025-06-10.."}, want: StatusPaused},
+		{start: "2025-01-01", end: "2025-06-01", pauses: []string{"2025-05-01.."}, want: StatusEnded},
+		{start: "2025-07-01", pauses: []string{"2025-07-01.."}, want: StatusScheduled},
+	}
+
+	date, _ := utils.ParseDate(at)
+	for _, tt := range tests {
+		sub := &Sub{Pauses: parsePauses(t, tt.pauses...)}
+		sub.StartDate, _ = utils.ParseDate(tt.start)
+		if tt.end != "" {
+			end, _ := utils.ParseDate(tt.end)
+			sub.EndDate = &end
+		}
+
+		if got := sub.StatusAt(date); got != tt.want {
+			t.Errorf("StatusAt(%s) of %s..%s paused %v = %s, want %s", at, tt.start, tt.end, tt.pauses, got, tt.want)
+		}
+	}
+}
@@ -1,28 +1,342 @@
 package domain
 
 import (
+	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/utils"
 )
 
 // Sub represents subscription model
 type Sub struct {
-	ID          uuid.UUID `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	ServiceName string    `json:"service_name" example:"Netflix"`
-	Price       int       `json:"price" example:"1000"`
-	UserID      uuid.UUID `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
-	StartDate   time.Time `json:"start_date" example:"01-2023"`
-	EndDate     time.Time `json:"end_date" example:"01-2023"`
+	ID           uuid.UUID        `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	ServiceName  string           `json:"service_name" example:"Netflix"`
+	ServiceID    uuid.UUID        `json:"service_id" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"` // сервис каталога, uuid.Nil до сопоставления по имени
+	Category     *string          `json:"category,omitempty" example:"entertainment"`                // по умолчанию категория сервиса каталога
+	Tags         []string         `json:"tags" example:"shared-with-partner"`
+	Price        Money            `json:"price"`
+	Phases       []PricePhase     `json:"phases"`
+	PriceHistory []PriceChange    `json:"price_history"` // Price равна регулярной цене, действующей сегодня
+	Pauses       []Pause          `json:"pauses"`
+	AllowOverlap bool             `json:"allow_overlap"` // разрешает пересечение с подписками того же пользователя на тот же сервис
+	UserID       uuid.UUID        `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	StartDate    time.Time        `json:"start_date" example:"2023-01-17"`
+	EndDate      *time.Time       `json:"end_date,omitempty" example:"2023-12-31"`
+	Billing      Billing          `json:"billing"`
+	DateFormat   utils.DateFormat `json:"date_format" example:"YYYY-MM-DD"` // формат, в котором клиент передал даты
+	Version      int              `json:"version" example:"1"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+	DeletedAt    *time.Time       `json:"deleted_at,omitempty"`
 }
 
-func New(serviceName string, price int, userID uuid.UUID, startDate time.Time, endDate time.Time) (*Sub, error) {
-	return &Sub{
+// MonthCost represents total cost of subscriptions in a single month
+type MonthCost struct {
+	Month time.Time
+	Cost  Money
+}
+
+// GroupCost represents cost of subscriptions with the same category or tag, Key is nil
+// for subscriptions without category or tags
+type GroupCost struct {
+	Key           *string
+	Cost          Money
+	Subscriptions int
+}
+
+// CostRow represents total of charges with the same values of grouping keys, only keys
+// the rows are grouped by are set. Service is identified by catalog service, Count is the number
+// of subscriptions charged
+type CostRow struct {
+	UserID      *uuid.UUID
+	ServiceID   *uuid.UUID
+	ServiceName *string
+	Category    *string
+	Month       *time.Time
+	Total       Money
+	Count       int
+}
+
+// TotalCost represents total cost of subscriptions for a period converted to Currency
+type TotalCost struct {
+	Currency   string
+	Total      Money
+	Months     []MonthCost
+	Currencies []CurrencyCost
+	Groups     []GroupCost
+	GroupBy    []CostGroupKey
+	Rows       []CostRow
+}
+
+const MaxServiceNameLength = 255
+
+// New создаёт подписку, endDate равный nil означает бессрочную подписку.
+// serviceID равный uuid.Nil означает, что сервис каталога ищется по serviceName
+func New(serviceName string, serviceID uuid.UUID, price Money, phases []PricePhase, userID uuid.UUID, startDate time.Time, endDate *time.Time, billing Billing, dateFormat utils.DateFormat) (*Sub, error) {
+	if dateFormat == "" {
+		dateFormat = utils.DateFormatMonthYear
+	}
+
+	sub := &Sub{
 		ID:          uuid.New(),
-		ServiceName: serviceName,
+		ServiceName: strings.TrimSpace(serviceName),
+		ServiceID:   serviceID,
 		Price:       price,
+		Phases:      phases,
 		UserID:      userID,
 		StartDate:   startDate,
 		EndDate:     endDate,
-	}, nil
+		Billing:     billing,
+		DateFormat:  dateFormat,
+	}
+
+	sub.PriceHistory = []PriceChange{{EffectiveFrom: startDate, Price: price}}
+
+	if err := sub.Validate(); err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// Validate проверяет инварианты подписки и возвращает ошибки по полям
+func (s *Sub) Validate() error {
+	verr := NewValidationError()
+
+	// Без имени подписка получает имя сервиса каталога
+	switch {
+	case s.ServiceName == "" && s.ServiceID == uuid.Nil:
+		verr.Add("service_name", "must not be empty")
+	case utf8.RuneCountInString(s.ServiceName) > MaxServiceNameLength:
+		verr.Add("service_name", fmt.Sprintf("must be at most %d characters", MaxServiceNameLength))
+	}
+
+	if s.Price.Amount <= 0 {
+		verr.Add("price", "must be positive")
+	}
+
+	if !ValidCurrency(s.Price.Currency) {
+		verr.Add("currency", "must be ISO-4217 currency code")
+	}
+
+	s.validatePhases(verr)
+
+	validateCategory(verr, "category", s.Category)
+	validateTags(verr, s.Tags)
+
+	if s.UserID == uuid.Nil {
+		verr.Add("user_id", "must not be empty")
+	}
+
+	if s.EndDate != nil && s.EndDate.Before(s.StartDate) {
+		verr.Add("end_date", "must not be before start_date")
+	}
+
+	s.Billing.validate(verr, s.StartDate)
+
+	if !s.DateFormat.Valid() {
+		verr.Add("date_format", "must be one of YYYY-MM-DD, YYYY-MM, MM-YYYY")
+	}
+
+	return verr.OrNil()
+}
+
+// CheckVersion проверяет, что версия подписки совпадает с одной из ожидаемых,
+// пустой список означает отсутствие предусловия
+func (s *Sub) CheckVersion(versions []int) error {
+	if len(versions) == 0 {
+		return nil
+	}
+
+	for _, version := range versions {
+		if s.Version == version {
+			return nil
+		}
+	}
+
+	return NewError(ErrPreconditionFailed, "subscription version does not match", fmt.Errorf("subscription %s has version %d", s.ID, s.Version))
+}
+
+// Apply применяет изменения из запроса на обновление к подписке по правилам JSON Merge Patch
+func (s *Sub) Apply(req *UpdateSubRequest) error {
+	verr := NewValidationError()
+
+	if req.ServiceName.Set {
+		if req.ServiceName.Null {
+			verr.Add("service_name", "must not be null")
+		}
+		s.ServiceName = req.ServiceName.Value
+	}
+
+	// Новое имя сопоставляется с каталогом заново, новый сервис без имени даёт подписке своё имя
+	switch {
+	case req.ServiceID.Set:
+		if req.ServiceID.Null {
+			verr.Add("service_id", "must not be null")
+		}
+		s.ServiceID = req.ServiceID.Value
+		if !req.ServiceName.Set {
+			s.ServiceName = ""
+		}
+	case req.ServiceName.Set:
+		s.ServiceID = uuid.Nil
+	}
+
+	// Дата начала применяется первой: по ней проверяются смена валюты и дата новой цены
+	if req.StartDate.Set {
+		if req.StartDate.Null {
+			verr.Add("start_date", "must not be null")
+		} else {
+			startDate, err := utils.ParseDate(req.StartDate.Value)
+			if err != nil {
+				verr.Add("start_date", "invalid date")
+			}
+			// Якорь по умолчанию, равный дате начала, переносится вместе с ней
+			if s.Billing.Anchor.Equal(s.StartDate) {
+				s.Billing.Anchor = startDate
+			}
+			s.StartDate = startDate
+		}
+	}
+
+	// Смена валюты без новой цены сохраняет значение цены в основных единицах. Валюта действует
+	// на всю историю цен, поэтому после начала подписки её смена изменила бы прошлые списания
+	if req.Currency.Set {
+		switch {
+		case req.Currency.Null:
+			verr.Add("currency", "must not be null")
+		case req.Currency.Value != s.Price.Currency && !s.StartDate.After(Today()):
+			verr.Add("currency", "can only be changed before start_date")
+		}
+		s.Price = s.Price.WithCurrency(req.Currency.Value)
+		for i := range s.Phases {
+			s.Phases[i].Price = s.Phases[i].Price.WithCurrency(req.Currency.Value)
+		}
+		for i := range s.PriceHistory {
+			s.PriceHistory[i].Price = s.PriceHistory[i].Price.WithCurrency(req.Currency.Value)
+		}
+	}
+
+	if req.Category.Set {
+		s.Category = nil
+		if req.Category.HasValue() {
+			s.Category = NormalizeCategory(&req.Category.Value)
+		}
+	}
+
+	// Метки заменяются целиком, null удаляет их
+	if req.Tags.Set {
+		s.Tags = NormalizeTags(req.Tags.Value)
+	}
+
+	// Новая цена не меняет историю, а добавляет в неё запись, действующую с price_effective_from
+	var price *Money
+	if req.Price.Set {
+		if req.Price.Null {
+			verr.Add("price", "must not be null")
+		} else {
+			parsed, err := ParseMoney(req.Price.Value.String(), s.Price.Currency)
+			if err != nil {
+				verr.Add("price", err.Error())
+			}
+			price = &parsed
+		}
+	}
+
+	// Фазы заменяются целиком, null удаляет их
+	if req.Phases.Set {
+		phases, perr := NewPricePhases(req.Phases.Value, s.Price.Currency)
+		if perr != nil {
+			verr.Fields = append(verr.Fields, perr.Fields...)
+		}
+		s.Phases = phases
+	}
+
+	if req.EndDate.Set {
+		if req.EndDate.Null {
+			s.EndDate = nil
+		} else {
+			endDate, err := utils.ParseDate(req.EndDate.Value)
+			if err != nil {
+				verr.Add("end_date", "invalid date")
+			}
+			s.EndDate = &endDate
+		}
+	}
+
+	if req.BillingPeriod.Set {
+		if req.BillingPeriod.Null {
+			verr.Add("billing_period", "must not be null")
+		}
+		s.Billing.Period = BillingPeriod(req.BillingPeriod.Value)
+		// При смене периода на фиксированный интервал сбрасывается, если не передан явно
+		if s.Billing.Period != BillingEveryNMonths && !req.BillingInterval.Set {
+			s.Billing.Interval = 1
+		}
+	}
+
+	if req.BillingInterval.Set {
+		s.Billing.Interval = req.BillingInterval.Value
+		if req.BillingInterval.Null {
+			s.Billing.Interval = 1
+		}
+	}
+
+	if req.BillingAnchor.Set {
+		if req.BillingAnchor.Null {
+			s.Billing.Anchor = s.StartDate
+		} else {
+			anchor, err := utils.ParseDate(req.BillingAnchor.Value)
+			if err != nil {
+				verr.Add("billing_anchor", "invalid date")
+			}
+			s.Billing.Anchor = anchor
+		}
+	}
+
+	if price != nil {
+		// По умолчанию новая цена действует с сегодняшнего дня, а у ещё не начавшейся подписки с её начала
+		effectiveFrom := Today()
+		if s.StartDate.After(effectiveFrom) {
+			effectiveFrom = s.StartDate
+		}
+		if req.PriceEffectiveFrom.HasValue() {
+			parsed, err := utils.ParseDate(req.PriceEffectiveFrom.Value)
+			if err != nil {
+				verr.Add("price_effective_from", "invalid date")
+			}
+			effectiveFrom = parsed
+		}
+
+		if effectiveFrom.Before(s.StartDate) {
+			verr.Add("price_effective_from", "must not be before start_date")
+		}
+
+		s.SetPrice(*price, effectiveFrom)
+	} else if req.PriceEffectiveFrom.Set {
+		verr.Add("price_effective_from", "is only allowed with price")
+	}
+
+	if req.DateFormat != "" {
+		s.DateFormat = req.DateFormat
+	}
+
+	if req.AllowOverlap != nil {
+		s.AllowOverlap = *req.AllowOverlap
+	}
+
+	if err := verr.OrNil(); err != nil {
+		return err
+	}
+
+	return s.Validate()
+}
+
+// Today возвращает текущую дату без времени
+func Today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
 }
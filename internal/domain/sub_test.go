@@ -0,0 +1,172 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newTestSub(t *testing.T, startDate time.Time) *Sub {
+	t.Helper()
+
+	sub, err := New("Netflix", uuid.Nil, NewMoney(49900, "RUB"), nil, uuid.New(), startDate, nil, NewBilling(BillingMonthly, 1, nil, startDate), "")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return sub
+}
+
+func TestSubApplyPrice(t *testing.T) {
+	today := Today()
+	scheduled := today.AddDate(0, 2, 0)
+	started := today.AddDate(0, -2, 0)
+
+	tests := []struct {
+		name          string
+		startDate     time.Time
+		req           UpdateSubRequest
+		wantErr       bool
+		wantEffective time.Time
+	}{
+		{
+			name:          "started subscription from today",
+			startDate:     started,
+			req:           UpdateSubRequest{Price: NewNullable(json.Number("599"))},
+			wantEffective: today,
+		},
+		{
+			name:          "scheduled subscription from start date",
+			startDate:     scheduled,
+			req:           UpdateSubRequest{Price: NewNullable(json.Number("599"))},
+			wantEffective: scheduled,
+		},
+		{
+			name:      "explicit date before start",
+			startDate: scheduled,
+			req: UpdateSubRequest{
+				Price:              NewNullable(json.Number("599")),
+				PriceEffectiveFrom: NewNullable(today.Format("2006-01-02")),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := newTestSub(t, tt.startDate)
+
+			err := sub.Apply(&tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			last := sub.PriceHistory[len(sub.PriceHistory)-1]
+			if !last.EffectiveFrom.Equal(tt.wantEffective) || last.Price.Amount != 59900 {
+				t.Errorf("last price change = %+v, want 599.00 from %s", last, tt.wantEffective)
+			}
+		})
+	}
+}
+
+func TestSubApplyCurrency(t *testing.T) {
+	today := Today()
+
+	tests := []struct {
+		name         string
+		startDate    time.Time
+		newStartDate string
+		currency     string
+		wantErr      bool
+	}{
+		{name: "scheduled subscription", startDate: today.AddDate(0, 0, 1), currency: "USD"},
+		{name: "started subscription", startDate: today, currency: "USD", wantErr: true},
+		{name: "same currency", startDate: today.AddDate(-1, 0, 0), currency: "RUB"},
+		{name: "scheduled subscription moved to the past", startDate: today.AddDate(0, 1, 0), newStartDate: today.AddDate(0, -1, 0).Format(time.DateOnly), currency: "USD", wantErr: true},
+		{name: "started subscription moved to the future", startDate: today.AddDate(0, -1, 0), newStartDate: today.AddDate(0, 1, 0).Format(time.DateOnly), currency: "USD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := newTestSub(t, tt.startDate)
+
+			req := UpdateSubRequest{Currency: NewNullable(tt.currency)}
+			if tt.newStartDate != "" {
+				req.StartDate = NewNullable(tt.newStartDate)
+			}
+
+			err := sub.Apply(&req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && sub.PriceHistory[0].Price.Currency != tt.currency {
+				t.Errorf("price history currency = %s, want %s", sub.PriceHistory[0].Price.Currency, tt.currency)
+			}
+		})
+	}
+}
+
+func TestSubApplyStartDateAnchor(t *testing.T) {
+	start := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		anchor     time.Time
+		req        UpdateSubRequest
+		wantAnchor time.Time
+		wantErr    bool
+	}{
+		{
+			name:       "default anchor moves with start date",
+			anchor:     start,
+			req:        UpdateSubRequest{StartDate: NewNullable("2025-03-10")},
+			wantAnchor: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "custom anchor stays",
+			anchor:     time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+			req:        UpdateSubRequest{StartDate: NewNullable("2025-01-20")},
+			wantAnchor: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "custom anchor before new start date",
+			anchor:  time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+			req:     UpdateSubRequest{StartDate: NewNullable("2025-03-01")},
+			wantErr: true,
+		},
+		{
+			name:   "explicit anchor with start date",
+			anchor: start,
+			req: UpdateSubRequest{
+				StartDate:     NewNullable("2025-03-01"),
+				BillingAnchor: NewNullable("2025-03-05"),
+			},
+			wantAnchor: time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "explicit anchor before start date",
+			anchor:  start,
+			req:     UpdateSubRequest{BillingAnchor: NewNullable("2025-01-01")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := newTestSub(t, start)
+			sub.Billing.Anchor = tt.anchor
+
+			err := sub.Apply(&tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !sub.Billing.Anchor.Equal(tt.wantAnchor) {
+				t.Errorf("billing anchor = %s, want %s", sub.Billing.Anchor.Format(time.DateOnly), tt.wantAnchor.Format(time.DateOnly))
+			}
+		})
+	}
+}
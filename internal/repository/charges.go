@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/maYkiss56/subscription-aggregation-service/internal/domain"
+)
+
+// convertedCTE строит CTE charges и converted со списаниями подписок в периоде, переведёнными
+// в целевую валюту по курсу на дату списания. У списаний без курса src_rate или tgt_rate равен NULL
+func convertedCTE(filter domain.TotalCostFilter, args *queryArgs) string {
+	charges := chargesCTE(filter, args)
+	base := args.add(domain.BaseCurrency)
+	target := args.add(filter.TargetCurrency)
+
+	return fmt.Sprintf(`%s,
+			converted AS (
+				SELECT c.*,
+					src.rate AS src_rate, tgt.rate AS tgt_rate,
+					round(
+						c.amount_minor * src.rate / tgt.rate
+						* power(10::numeric, %s - %s)
+					)::bigint AS amount
+				FROM charges c
+				CROSS JOIN LATERAL (SELECT %s AS rate) src
+				CROSS JOIN LATERAL (SELECT %s AS rate) tgt
+			)`,
+		charges,
+		minorUnitsExpr(target+"::text"), minorUnitsExpr("c.currency"),
+		rateAt("c.currency", "c.charge_date", base), rateAt(target+"::text", "c.charge_date", base),
+	)
+}
+
+// chargesCTE строит CTE charges со всеми списаниями подписок в периоде [from, to].
+// Списания происходят в billing_anchor + n * шаг периода, пока подписка активна.
+// Для вычисления n сразу берётся диапазон шагов, попадающих в период, чтобы не
+// перебирать списания подписки с самой даты якоря.
+//
+// При политике billing_day списание учитывается целиком, если день списания попадает
+// в период и подписка в этот день активна. При политике daily учитывается каждый цикл
+// списания, пересекающийся с периодом, а его цена пропорциональна числу дней цикла, в
+// которые подписка активна внутри периода; такое списание относится к первому из этих дней.
+// Пропорциональная цена округляется до минимальных единиц половиной от нуля.
+// Цена списания берётся на день списания по правилам priceAt.
+// Дни, в которые подписка на паузе, не считаются днями активности: при billing_day списание в
+// такой день пропускается, при daily такие дни не входят в пропорцию.
+func chargesCTE(filter domain.TotalCostFilter, args *queryArgs) string {
+	from := args.add(filter.StartPeriod)
+	to := args.add(filter.EndPeriod)
+
+	conds := []string{
+		"s.deleted_at IS NULL",
+		fmt.Sprintf("s.start_date <= %s::date", to),
+		fmt.Sprintf("(s.end_date >= %s::date OR s.end_date IS NULL)", from),
+	}
+	if filter.UserID != nil {
+		conds = append(conds, "s.user_id = "+args.add(*filter.UserID))
+	}
+	if filter.ServiceName != nil {
+		conds = append(conds, serviceNameCond("s.service_id", args.add(domain.NormalizeServiceName(*filter.ServiceName))))
+	}
+	if filter.ServiceID != nil {
+		conds = append(conds, "s.service_id = "+args.add(*filter.ServiceID))
+	}
+	if filter.Category != nil {
+		conds = append(conds, "s.category = "+args.add(*filter.Category))
+	}
+	if len(filter.Tags) > 0 {
+		conds = append(conds, tagsCond("s.id", args.add(filter.Tags)))
+	}
+
+	// Первый и последний день цикла, в которые подписка активна внутри периода
+	activeFrom := fmt.Sprintf("GREATEST(c.cycle_start, s.start_date, %s::date)", from)
+	activeTo := fmt.Sprintf("LEAST(c.cycle_end - 1, COALESCE(s.end_date, %[1]s::date), %[1]s::date)", to)
+
+	var chargeDate, selectCharge, pausedJoin string
+	if filter.Proration == domain.ProrationDaily {
+		chargeDate = activeFrom
+		activeDays := fmt.Sprintf("(%s - %s + 1 - paused.days)", activeTo, activeFrom)
+		selectCharge = fmt.Sprintf(`round(
+						price.price_minor::numeric * %[2]s / (c.cycle_end - c.cycle_start)
+					)::bigint AS amount_minor, s.currency, %[1]s AS charge_date`, activeFrom, activeDays)
+		// Паузы подписки не пересекаются, поэтому дни пауз внутри цикла можно просто сложить
+		pausedJoin = fmt.Sprintf(`
+				CROSS JOIN LATERAL (
+					SELECT COALESCE(SUM(GREATEST(0,
+						LEAST(%[2]s, COALESCE(sp.paused_to, %[2]s)) - GREATEST(%[1]s, sp.paused_from) + 1
+					)), 0) AS days
+					FROM subscription_pauses sp
+					WHERE sp.sub_id = s.id
+				) paused`, activeFrom, activeTo)
+		conds = append(conds, fmt.Sprintf("%s <= %s", activeFrom, activeTo), activeDays+" > 0")
+	} else {
+		chargeDate = "c.cycle_start"
+		selectCharge = "price.price_minor AS amount_minor, s.currency, c.cycle_start AS charge_date"
+		conds = append(conds,
+			fmt.Sprintf(
+				"c.cycle_start BETWEEN GREATEST(%[1]s::date, s.start_date) AND LEAST(%[2]s::date, COALESCE(s.end_date, %[2]s::date))",
+				from, to,
+			),
+			`NOT EXISTS (
+					SELECT 1 FROM subscription_pauses sp
+					WHERE sp.sub_id = s.id
+					AND c.cycle_start >= sp.paused_from
+					AND (sp.paused_to IS NULL OR c.cycle_start <= sp.paused_to)
+				)`,
+		)
+	}
+
+	return fmt.Sprintf(`
+			charges AS (
+				SELECT s.id, s.user_id, s.service_id, s.service_name, s.category,
+					%[4]s
+				FROM subscriptions s
+				CROSS JOIN LATERAL (
+					SELECT
+						CASE s.billing_period
+							WHEN 'weekly' THEN 0
+							WHEN 'quarterly' THEN 3
+							WHEN 'yearly' THEN 12
+							ELSE s.billing_interval
+						END AS months,
+						CASE s.billing_period
+							WHEN 'weekly' THEN 7
+							ELSE 0
+						END AS days
+				) step
+				CROSS JOIN LATERAL (
+					SELECT
+						(s.billing_anchor + n * make_interval(months => step.months, days => step.days))::date AS cycle_start,
+						(s.billing_anchor + (n + 1) * make_interval(months => step.months, days => step.days))::date AS cycle_end
+					FROM generate_series(
+						GREATEST(0, %[1]s),
+						%[2]s
+					) AS n
+				) c
+				CROSS JOIN LATERAL (SELECT %[5]s AS price_minor) price%[6]s
+				WHERE %[3]s
+			)`,
+		stepsUntil(from), stepsUntil(to),
+		strings.Join(conds, " AND "),
+		selectCharge,
+		priceAt(chargeDate),
+		pausedJoin,
+	)
+}
+
+// priceAt возвращает выражение цены подписки s в минимальных единицах на дату date: цену фазы,
+// действующей в эту дату, после фаз регулярную цену из истории цен на эту дату; до первой записи
+// истории действует её цена
+func priceAt(date string) string {
+	return fmt.Sprintf(`COALESCE((
+						SELECT p.price_minor
+						FROM (
+							SELECT ph.price_minor, SUM(ph.months) OVER (ORDER BY ph.position) AS end_months
+							FROM subscription_phases ph
+							WHERE ph.sub_id = s.id
+						) p
+						WHERE %[1]s < s.start_date + make_interval(months => p.end_months::int)
+						ORDER BY p.end_months
+						LIMIT 1
+					), (
+						SELECT sp.price_minor
+						FROM subscription_prices sp
+						WHERE sp.sub_id = s.id AND sp.effective_from <= %[1]s
+						ORDER BY sp.effective_from DESC
+						LIMIT 1
+					), (
+						SELECT sp.price_minor
+						FROM subscription_prices sp
+						WHERE sp.sub_id = s.id
+						ORDER BY sp.effective_from
+						LIMIT 1
+					), s.price_minor)`, date)
+}
+
+// stepsUntil возвращает число целых шагов периода списаний от billing_anchor до даты
+func stepsUntil(date string) string {
+	return fmt.Sprintf(`CASE
+							WHEN step.days > 0 THEN (%[1]s::date - s.billing_anchor) / step.days
+							ELSE (
+								EXTRACT(YEAR FROM age(%[1]s::date, s.billing_anchor)) * 12
+								+ EXTRACT(MONTH FROM age(%[1]s::date, s.billing_anchor))
+							)::int / step.months
+						END`, date)
+}
+
+// rateAt возвращает выражение курса валюты на дату: последний курс, действующий на эту дату.
+// Курс базовой валюты всегда равен 1, при отсутствии курса выражение равно NULL
+func rateAt(currency, date, base string) string {
+	return fmt.Sprintf(`CASE
+					WHEN %[1]s = %[3]s THEN 1::numeric
+					ELSE (
+						SELECT r.rate
+						FROM exchange_rates r
+						WHERE r.currency = %[1]s
+						AND r.rate_date <= %[2]s
+						ORDER BY r.rate_date DESC
+						LIMIT 1
+					)
+				END`, currency, date, base)
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/domain"
+)
+
+// Коды ошибок PostgreSQL, см. https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgUniqueViolation     = "23505"
+	pgExclusionViolation  = "23P01"
+	pgForeignKeyViolation = "23503"
+	pgCheckViolation      = "23514"
+	pgNotNullViolation    = "23502"
+
+	pgDataExceptionClass      = "22"
+	pgConnectionClass         = "08"
+	pgInsufficientResources   = "53"
+	pgOperatorInterventionCls = "57"
+)
+
+// wrapError приводит ошибку pgx к доменной ошибке с сообщением о сущности entity, сохраняя исходную причину
+func wrapError(err error, entity, op string) error {
+	err = fmt.Errorf("%s: %w", op, err)
+
+	var pgErr *pgconn.PgError
+	var connErr *pgconn.ConnectError
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return domain.NewError(domain.ErrNotFound, entity+" not found", err)
+	case errors.As(err, &connErr), errors.Is(err, context.DeadlineExceeded):
+		return domain.NewError(domain.ErrUnavailable, "database is unavailable", err)
+	case errors.As(err, &pgErr):
+		switch {
+		case pgErr.Code == pgUniqueViolation, pgErr.Code == pgExclusionViolation:
+			return domain.NewError(domain.ErrConflict, entity+" conflicts with existing data", err)
+		case pgErr.Code == pgForeignKeyViolation,
+			pgErr.Code == pgCheckViolation,
+			pgErr.Code == pgNotNullViolation,
+			strings.HasPrefix(pgErr.Code, pgDataExceptionClass):
+			return domain.NewError(domain.ErrValidation, entity+" data is invalid", err)
+		case strings.HasPrefix(pgErr.Code, pgConnectionClass),
+			strings.HasPrefix(pgErr.Code, pgInsufficientResources),
+			strings.HasPrefix(pgErr.Code, pgOperatorInterventionCls):
+			return domain.NewError(domain.ErrUnavailable, "database is unavailable", err)
+		}
+	}
+
+	return err
+}
+
+// wrapServiceError приводит ошибку запроса к каталогу сервисов к доменной ошибке с сообщением о сервисе
+func wrapServiceError(err error, op string) error {
+	var pgErr *pgconn.PgError
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return domain.NewError(domain.ErrNotFound, "service not found", fmt.Errorf("%s: %w", op, err))
+	case errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation:
+		return domain.NewError(domain.ErrConflict, "service name or alias is already taken", fmt.Errorf("%s: %w", op, err))
+	case errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation:
+		return domain.NewError(domain.ErrConflict, "service is used by subscriptions", fmt.Errorf("%s: %w", op, err))
+	}
+
+	return wrapError(err, "service", op)
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/maYkiss56/subscription-aggregation-service/internal/domain"
+)
+
+// queryArgs накапливает аргументы запроса и возвращает их плейсхолдеры
+type queryArgs []interface{}
+
+func (a *queryArgs) add(v interface{}) string {
+	*a = append(*a, v)
+	return fmt.Sprintf("$%d", len(*a))
+}
+
+// minorUnitsExpr возвращает выражение числа знаков после запятой для валюты по ISO-4217
+func minorUnitsExpr(currency string) string {
+	exceptions := domain.MinorUnitsExceptions()
+
+	codes := make([]string, 0, len(exceptions))
+	for code := range exceptions {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "CASE %s", currency)
+	for _, code := range codes {
+		fmt.Fprintf(&b, " WHEN '%s' THEN %d", code, exceptions[code])
+	}
+	fmt.Fprintf(&b, " ELSE %d END", domain.DefaultMinorUnits)
+
+	return b.String()
+}
+
+// serviceNameCond возвращает условие на сервис каталога, нормализованное имя или псевдоним которого равно name
+func serviceNameCond(column, name string) string {
+	return fmt.Sprintf("%s IN (SELECT service_id FROM service_names WHERE normalized_name = %s)", column, name)
+}
+
+// tagsCond возвращает условие, что у подписки subID есть все метки из массива tags
+func tagsCond(subID, tags string) string {
+	return fmt.Sprintf(`%s::text[] <@ ARRAY(
+			SELECT t.name FROM subscription_tags st
+			JOIN tags t ON t.id = st.tag_id
+			WHERE st.sub_id = %s
+		)`, tags, subID)
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/domain"
+	"github.com/maYkiss56/subscription-aggregation-service/pkg/client/postgresql"
+)
+
+type RateRepository struct {
+	pg *postgresql.PostgresClient
+}
+
+func NewRateRepository(pg *postgresql.PostgresClient) *RateRepository {
+	return &RateRepository{pg: pg}
+}
+
+// UpsertRates сохраняет курсы валют, заменяя курсы на те же даты
+func (r *RateRepository) UpsertRates(ctx context.Context, rates []domain.ExchangeRate) (int, error) {
+	conn, err := r.pg.GetConnection(ctx)
+	if err != nil {
+		return 0, wrapError(err, "exchange rate", "failed to get connection")
+	}
+	defer conn.Release()
+
+	query := `
+		insert into exchange_rates (currency, rate_date, rate)
+		values ($1, $2, $3)
+		on conflict (currency, rate_date) do update set rate = excluded.rate
+	`
+
+	batch := &pgx.Batch{}
+	for _, rate := range rates {
+		batch.Queue(query, rate.Currency, rate.Date, rate.Rate)
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return 0, wrapError(err, "exchange rate", "failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return 0, wrapError(err, "exchange rate", "failed to upsert exchange rates")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, wrapError(err, "exchange rate", "failed to commit transaction")
+	}
+
+	return len(rates), nil
+}
+
+// GetRates возвращает курсы валюты, отсортированные по дате
+func (r *RateRepository) GetRates(ctx context.Context, currency string) ([]domain.ExchangeRate, error) {
+	conn, err := r.pg.GetConnection(ctx)
+	if err != nil {
+		return nil, wrapError(err, "exchange rate", "failed to get connection")
+	}
+	defer conn.Release()
+
+	query := `select currency, rate_date, rate::float8
+		from exchange_rates
+		where currency=$1
+		order by rate_date
+	`
+
+	rows, err := conn.Query(ctx, query, currency)
+	if err != nil {
+		return nil, wrapError(err, "exchange rate", "failed to query exchange rates")
+	}
+	defer rows.Close()
+
+	var rates []domain.ExchangeRate
+	for rows.Next() {
+		var rate domain.ExchangeRate
+		if err := rows.Scan(&rate.Currency, &rate.Date, &rate.Rate); err != nil {
+			return nil, wrapError(err, "exchange rate", "failed to scan exchange rate")
+		}
+		rates = append(rates, rate)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err, "exchange rate", "rows error")
+	}
+
+	return rates, nil
+}
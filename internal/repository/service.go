@@ -0,0 +1,499 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/domain"
+	"github.com/maYkiss56/subscription-aggregation-service/pkg/client/postgresql"
+)
+
+// serviceColumns перечисляет колонки сервиса в порядке, который ожидает scanService,
+// псевдонимы собираются в JSON-массив по алфавиту
+const serviceColumns = `id, name, category, homepage, default_price_minor, default_currency,
+	created_at, updated_at,
+	(
+		SELECT COALESCE(json_agg(n.name ORDER BY n.name), '[]')
+		FROM service_names n
+		WHERE n.service_id = services.id AND n.is_alias
+	) AS aliases`
+
+type ServiceRepository struct {
+	pg *postgresql.PostgresClient
+}
+
+func NewServiceRepository(pg *postgresql.PostgresClient) *ServiceRepository {
+	return &ServiceRepository{pg: pg}
+}
+
+// scanService читает колонки serviceColumns, а за ними в extra колонки, выбранные запросом дополнительно
+func scanService(row pgx.Row, service *domain.Service, extra ...interface{}) error {
+	var (
+		priceMinor *int64
+		currency   *string
+	)
+
+	dest := []interface{}{
+		&service.ID,
+		&service.Name,
+		&service.Category,
+		&service.Homepage,
+		&priceMinor,
+		&currency,
+		&service.CreatedAt,
+		&service.UpdatedAt,
+		&service.Aliases,
+	}
+
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+
+	service.DefaultPrice = nil
+	if priceMinor != nil && currency != nil {
+		price := domain.NewMoney(*priceMinor, *currency)
+		service.DefaultPrice = &price
+	}
+
+	return nil
+}
+
+// defaultPriceArgs возвращает цену по умолчанию в виде значений колонок, NULL если её нет
+func defaultPriceArgs(service *domain.Service) (*int64, *string) {
+	if service.DefaultPrice == nil {
+		return nil, nil
+	}
+	return &service.DefaultPrice.Amount, &service.DefaultPrice.Currency
+}
+
+// replaceServiceNames заменяет каноничное имя и псевдонимы сервиса внутри транзакции
+func replaceServiceNames(ctx context.Context, tx pgx.Tx, service *domain.Service) error {
+	if _, err := tx.Exec(ctx, `delete from service_names where service_id=$1`, service.ID); err != nil {
+		return err
+	}
+
+	query := `
+		insert into service_names (normalized_name, service_id, name, is_alias)
+		values ($1, $2, $3, $4)
+	`
+
+	batch := &pgx.Batch{}
+	batch.Queue(query, domain.NormalizeServiceName(service.Name), service.ID, service.Name, false)
+	for _, alias := range service.Aliases {
+		batch.Queue(query, domain.NormalizeServiceName(alias), service.ID, alias, true)
+	}
+
+	return tx.SendBatch(ctx, batch).Close()
+}
+
+func (r *ServiceRepository) CreateService(ctx context.Context, service *domain.Service) (*domain.Service, error) {
+	conn, err := r.pg.GetConnection(ctx)
+	if err != nil {
+		return nil, wrapError(err, "service", "failed to get connection")
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, wrapError(err, "service", "failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		insert into services (id, name, category, homepage, default_price_minor, default_currency)
+		values ($1, $2, $3, $4, $5, $6)
+		returning created_at, updated_at
+	`
+
+	priceMinor, currency := defaultPriceArgs(service)
+	err = tx.QueryRow(ctx, query,
+		service.ID,
+		service.Name,
+		service.Category,
+		service.Homepage,
+		priceMinor,
+		currency,
+	).Scan(&service.CreatedAt, &service.UpdatedAt)
+	if err != nil {
+		return nil, wrapServiceError(err, "failed to create service")
+	}
+
+	if err := replaceServiceNames(ctx, tx, service); err != nil {
+		return nil, wrapServiceError(err, "failed to create service names")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, wrapError(err, "service", "failed to commit transaction")
+	}
+
+	return service, nil
+}
+
+// GetServices возвращает сервисы каталога, отсортированные по имени
+func (r *ServiceRepository) GetServices(ctx context.Context) ([]*domain.Service, error) {
+	conn, err := r.pg.GetConnection(ctx)
+	if err != nil {
+		return nil, wrapError(err, "service", "failed to get connection")
+	}
+	defer conn.Release()
+
+	query := `select ` + serviceColumns + `
+		from services
+		order by lower(name), id
+	`
+
+	rows, err := conn.Query(ctx, query)
+	if err != nil {
+		return nil, wrapError(err, "service", "failed to query services")
+	}
+	defer rows.Close()
+
+	var services []*domain.Service
+	for rows.Next() {
+		var service domain.Service
+		if err := scanService(rows, &service); err != nil {
+			return nil, wrapError(err, "service", "failed to scan service")
+		}
+		services = append(services, &service)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err, "service", "rows error")
+	}
+
+	return services, nil
+}
+
+func (r *ServiceRepository) GetServiceByID(ctx context.Context, id uuid.UUID) (*domain.Service, error) {
+	conn, err := r.pg.GetConnection(ctx)
+	if err != nil {
+		return nil, wrapError(err, "service", "failed to get connection")
+	}
+	defer conn.Release()
+
+	query := `select ` + serviceColumns + `
+		from services
+		where id=$1
+	`
+
+	var service domain.Service
+	if err := scanService(conn.QueryRow(ctx, query, id), &service); err != nil {
+		return nil, wrapServiceError(err, "failed to get service by id")
+	}
+
+	return &service, nil
+}
+
+// UpdateService блокирует сервис, применяет к нему update и сохраняет результат в одной транзакции
+func (r *ServiceRepository) UpdateService(ctx context.Context, id uuid.UUID, update func(service *domain.Service) error) (*domain.Service, error) {
+	conn, err := r.pg.GetConnection(ctx)
+	if err != nil {
+		return nil, wrapError(err, "service", "failed to get connection")
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, wrapError(err, "service", "failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	selectQuery := `select ` + serviceColumns + `
+		from services
+		where id=$1
+		for update
+	`
+
+	var service domain.Service
+	if err := scanService(tx.QueryRow(ctx, selectQuery, id), &service); err != nil {
+		return nil, wrapServiceError(err, "failed to get service for update")
+	}
+
+	if err := update(&service); err != nil {
+		return nil, err
+	}
+
+	updateQuery := `
+			UPDATE services
+			SET
+				name = $1,
+				category = $2,
+				homepage = $3,
+				default_price_minor = $4,
+				default_currency = $5,
+				updated_at = now()
+			WHERE id = $6
+			RETURNING updated_at
+		`
+
+	priceMinor, currency := defaultPriceArgs(&service)
+	err = tx.QueryRow(ctx, updateQuery,
+		service.Name,
+		service.Category,
+		service.Homepage,
+		priceMinor,
+		currency,
+		id,
+	).Scan(&service.UpdatedAt)
+	if err != nil {
+		return nil, wrapServiceError(err, "failed to update service")
+	}
+
+	if err := replaceServiceNames(ctx, tx, &service); err != nil {
+		return nil, wrapServiceError(err, "failed to update service names")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, wrapError(err, "service", "failed to commit transaction")
+	}
+
+	return &service, nil
+}
+
+// DeleteService удаляет сервис из каталога, если на него не ссылается ни одна подписка, включая удалённые
+func (r *ServiceRepository) DeleteService(ctx context.Context, id uuid.UUID) error {
+	conn, err := r.pg.GetConnection(ctx)
+	if err != nil {
+		return wrapError(err, "service", "failed to get connection")
+	}
+	defer conn.Release()
+
+	cmd, err := conn.Exec(ctx, `DELETE FROM services WHERE id = $1`, id)
+	if err != nil {
+		return wrapServiceError(err, "failed to delete service")
+	}
+
+	if cmd.RowsAffected() == 0 {
+		return domain.NewError(domain.ErrNotFound, "service not found", fmt.Errorf("service %s", id))
+	}
+
+	return nil
+}
+
+// likePrefix экранирует спецсимволы LIKE и возвращает шаблон поиска по префиксу
+func likePrefix(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s) + "%"
+}
+
+// SuggestServices возвращает сервисы, имя или псевдоним которых начинается с нормализованного запроса
+// или похож на него, сначала совпадения по префиксу, затем по убыванию сходства
+func (r *ServiceRepository) SuggestServices(ctx context.Context, query string, limit int) ([]domain.ServiceSuggestion, error) {
+	conn, err := r.pg.GetConnection(ctx)
+	if err != nil {
+		return nil, wrapError(err, "service", "failed to get connection")
+	}
+	defer conn.Release()
+
+	normalized := domain.NormalizeServiceName(query)
+
+	// Для каждого сервиса остаётся лучше всего подходящее имя или псевдоним. word_similarity
+	// сравнивает запрос с самой похожей частью имени, чтобы находить имена по началу слов
+	suggestQuery := `select ` + serviceColumns + `, m.matched_name, m.score
+		from (
+			select distinct on (n.service_id)
+				n.service_id, n.name as matched_name,
+				n.normalized_name like $2 as prefix,
+				greatest(similarity(n.normalized_name, $1), word_similarity($1, n.normalized_name))::float8 as score
+			from service_names n
+			where n.normalized_name like $2
+			or similarity(n.normalized_name, $1) >= $3
+			or word_similarity($1, n.normalized_name) >= $3
+			order by n.service_id, prefix desc, score desc, n.is_alias
+		) m
+		join services on services.id = m.service_id
+		order by m.prefix desc, m.score desc, lower(services.name), services.id
+		limit $4
+	`
+
+	rows, err := conn.Query(ctx, suggestQuery, normalized, likePrefix(normalized), domain.ServiceSuggestSimilarity, limit)
+	if err != nil {
+		return nil, wrapError(err, "service", "failed to suggest services")
+	}
+	defer rows.Close()
+
+	var suggestions []domain.ServiceSuggestion
+	for rows.Next() {
+		var (
+			service    domain.Service
+			suggestion domain.ServiceSuggestion
+		)
+		if err := scanService(rows, &service, &suggestion.MatchedName, &suggestion.Similarity); err != nil {
+			return nil, wrapError(err, "service", "failed to scan service suggestion")
+		}
+		suggestion.Service = &service
+		suggestions = append(suggestions, suggestion)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err, "service", "rows error")
+	}
+
+	return suggestions, nil
+}
+
+// RenormalizeServiceNames пересчитывает нормализованные имена и псевдонимы сервисов после изменения
+// правил нормализации в одной транзакции. Сервисы, имена которых стали совпадать, сливаются в самый
+// старый из них: подписки переходят к нему, имена остальных становятся его псевдонимами, а сами они
+// удаляются. Возвращает число изменённых имён и удалённых сервисов
+func (r *ServiceRepository) RenormalizeServiceNames(ctx context.Context) (renamed, merged int, err error) {
+	conn, err := r.pg.GetConnection(ctx)
+	if err != nil {
+		return 0, 0, wrapError(err, "service", "failed to get connection")
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return 0, 0, wrapError(err, "service", "failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	// Новые имена не должны появиться, пока каталог перестраивается
+	if _, err := tx.Exec(ctx, `lock table services, service_names in share row exclusive mode`); err != nil {
+		return 0, 0, wrapError(err, "service", "failed to lock services")
+	}
+
+	rows, err := tx.Query(ctx, `
+		select n.normalized_name, n.service_id, n.name, n.is_alias
+		from service_names n
+		join services s on s.id = n.service_id
+		order by s.created_at, s.id, n.is_alias, n.name
+	`)
+	if err != nil {
+		return 0, 0, wrapError(err, "service", "failed to query service names")
+	}
+
+	var names []serviceName
+	for rows.Next() {
+		var name serviceName
+		if err := rows.Scan(&name.normalized, &name.serviceID, &name.name, &name.isAlias); err != nil {
+			rows.Close()
+			return 0, 0, wrapError(err, "service", "failed to scan service name")
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, 0, wrapError(err, "service", "rows error")
+	}
+
+	renamed, canonical, result := renormalizeServiceNames(names)
+	if renamed == 0 {
+		return 0, 0, nil
+	}
+
+	if _, err := tx.Exec(ctx, `delete from service_names`); err != nil {
+		return 0, 0, wrapError(err, "service", "failed to clear service names")
+	}
+
+	batch := &pgx.Batch{}
+	for _, name := range result {
+		batch.Queue(`
+			insert into service_names (normalized_name, service_id, name, is_alias)
+			values ($1, $2, $3, $4)
+		`, name.normalized, name.serviceID, name.name, name.isAlias)
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return 0, 0, wrapError(err, "service", "failed to save service names")
+	}
+
+	for duplicate, target := range canonical {
+		if _, err := tx.Exec(ctx, `update subscriptions set service_id=$2 where service_id=$1`, duplicate, target); err != nil {
+			return 0, 0, wrapError(err, "service", "failed to move subscriptions to merged service")
+		}
+		if _, err := tx.Exec(ctx, `delete from services where id=$1`, duplicate); err != nil {
+			return 0, 0, wrapError(err, "service", "failed to delete merged service")
+		}
+		merged++
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, 0, wrapError(err, "service", "failed to commit transaction")
+	}
+
+	return renamed, merged, nil
+}
+
+// serviceName представляет строку service_names
+type serviceName struct {
+	normalized string
+	serviceID  uuid.UUID
+	name       string
+	isAlias    bool
+}
+
+// renormalizeServiceNames пересчитывает нормализованные имена. names должны идти от старых сервисов
+// к новым, у каждого сервиса каноничное имя первым. Сервисы с общим нормализованным именем сливаются
+// в первый из них, canonical сопоставляет слитому сервису оставшийся. Нормализованное имя достаётся
+// первому имени, каноничное имя слитого сервиса становится псевдонимом оставшегося
+func renormalizeServiceNames(names []serviceName) (renamed int, canonical map[uuid.UUID]uuid.UUID, result []serviceName) {
+	parent := make(map[uuid.UUID]uuid.UUID)
+	var find func(id uuid.UUID) uuid.UUID
+	find = func(id uuid.UUID) uuid.UUID {
+		if p, ok := parent[id]; ok && p != id {
+			root := find(p)
+			parent[id] = root
+			return root
+		}
+		return id
+	}
+
+	// Корнем становится сервис, встреченный раньше, то есть более старый
+	age := make(map[uuid.UUID]int)
+	owner := make(map[string]uuid.UUID)
+	for i, name := range names {
+		normalized := domain.NormalizeServiceName(name.name)
+		if normalized != name.normalized {
+			renamed++
+			names[i].normalized = normalized
+		}
+		if _, ok := parent[name.serviceID]; !ok {
+			parent[name.serviceID] = name.serviceID
+			age[name.serviceID] = len(age)
+		}
+
+		first, ok := owner[normalized]
+		if !ok {
+			owner[normalized] = name.serviceID
+			continue
+		}
+
+		a, b := find(first), find(name.serviceID)
+		if age[b] < age[a] {
+			a, b = b, a
+		}
+		if a != b {
+			parent[b] = a
+		}
+	}
+
+	canonical = make(map[uuid.UUID]uuid.UUID)
+	for id := range parent {
+		if root := find(id); root != id {
+			canonical[id] = root
+		}
+	}
+	if renamed == 0 && len(canonical) == 0 {
+		return 0, nil, nil
+	}
+
+	taken := make(map[string]bool)
+	for _, name := range names {
+		if taken[name.normalized] {
+			continue
+		}
+		taken[name.normalized] = true
+
+		if root, ok := canonical[name.serviceID]; ok {
+			name.serviceID = root
+			name.isAlias = true
+		}
+		result = append(result, name)
+	}
+
+	return renamed, canonical, result
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestRenormalizeServiceNames(t *testing.T) {
+	older, newer, other := uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name      string
+		names     []serviceName
+		renamed   int
+		canonical map[uuid.UUID]uuid.UUID
+		result    []serviceName
+	}{
+		{
+			name: "nothing changed",
+			names: []serviceName{
+				{normalized: "netflix", serviceID: older, name: "Netflix"},
+				{normalized: "youtube premium", serviceID: other, name: "YouTube Premium"},
+			},
+		},
+		{
+			name: "renamed without conflicts",
+			names: []serviceName{
+				{normalized: "yt premium", serviceID: older, name: "YT Premium"},
+				{normalized: "disney+", serviceID: other, name: "Disney+"},
+			},
+			renamed:   2,
+			canonical: map[uuid.UUID]uuid.UUID{},
+			result: []serviceName{
+				{normalized: "youtube premium", serviceID: older, name: "YT Premium"},
+				{normalized: "disney", serviceID: other, name: "Disney+"},
+			},
+		},
+		{
+			name: "duplicates merged into older service",
+			names: []serviceName{
+				{normalized: "youtube premium", serviceID: older, name: "YouTube Premium"},
+				{normalized: "yt", serviceID: older, name: "YT", isAlias: true},
+				{normalized: "yt prem", serviceID: newer, name: "YT Prem"},
+				{normalized: "ytp", serviceID: newer, name: "YTP", isAlias: true},
+				{normalized: "netflix", serviceID: other, name: "Netflix"},
+			},
+			renamed:   2,
+			canonical: map[uuid.UUID]uuid.UUID{newer: older},
+			result: []serviceName{
+				{normalized: "youtube premium", serviceID: older, name: "YouTube Premium"},
+				{normalized: "youtube", serviceID: older, name: "YT", isAlias: true},
+				{normalized: "ytp", serviceID: older, name: "YTP", isAlias: true},
+				{normalized: "netflix", serviceID: other, name: "Netflix"},
+			},
+		},
+		{
+			name: "merged through alias of newer service",
+			names: []serviceName{
+				{normalized: "spotify", serviceID: older, name: "Spotify"},
+				{normalized: "spotify fam", serviceID: other, name: "Spotify Fam"},
+				{normalized: "spotify family", serviceID: newer, name: "Spotify Family"},
+			},
+			renamed:   1,
+			canonical: map[uuid.UUID]uuid.UUID{newer: other},
+			result: []serviceName{
+				{normalized: "spotify", serviceID: older, name: "Spotify"},
+				{normalized: "spotify family", serviceID: other, name: "Spotify Fam"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renamed, canonical, result := renormalizeServiceNames(tt.names)
+
+			if renamed != tt.renamed {
+				t.Errorf("renamed = %d, want %d", renamed, tt.renamed)
+			}
+
+			if len(canonical) != len(tt.canonical) {
+				t.Errorf("canonical = %v, want %v", canonical, tt.canonical)
+			}
+			for id, root := range tt.canonical {
+				if canonical[id] != root {
+					t.Errorf("canonical[%s] = %s, want %s", id, canonical[id], root)
+				}
+			}
+
+			if len(result) != len(tt.result) {
+				t.Fatalf("result = %+v, want %+v", result, tt.result)
+			}
+			for i := range result {
+				if result[i] != tt.result[i] {
+					t.Errorf("result[%d] = %+v, want %+v", i, result[i], tt.result[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/domain"
+	"github.com/maYkiss56/subscription-aggregation-service/pkg/client/postgresql"
+)
+
+type StatsRepository struct {
+	pg *postgresql.PostgresClient
+}
+
+func NewStatsRepository(pg *postgresql.PostgresClient) *StatsRepository {
+	return &StatsRepository{pg: pg}
+}
+
+// statsColumns перечисляет агрегаты статистики подписок из statsCTE в порядке, который ожидает statsRow.
+// Подписки, приостановленные на весь месяц или период, учитываются только в новых и закончившихся
+const statsColumns = `
+	COUNT(DISTINCT a.user_id) FILTER (WHERE NOT a.paused),
+	COUNT(a.id) FILTER (WHERE NOT a.paused),
+	COUNT(a.id) FILTER (WHERE a.start_date >= a.bucket_start),
+	COUNT(a.id) FILTER (WHERE a.end_date <= a.bucket_end),
+	round(AVG(a.monthly_price) FILTER (WHERE NOT a.paused))::bigint,
+	round(percentile_cont(0.5) WITHIN GROUP (ORDER BY a.monthly_price::float8) FILTER (WHERE NOT a.paused))::bigint,
+	round(AVG((a.ref_date - a.start_date + 1) / (365.25 / 12)) FILTER (WHERE NOT a.paused), 2)::float8,
+	COALESCE(array_agg(DISTINCT a.currency) FILTER (WHERE NOT a.paused AND a.src_rate IS NULL), '{}'),
+	COALESCE(bool_or(NOT a.paused AND a.tgt_rate IS NULL), false)`
+
+// statsCTE строит CTE buckets с месяцами периода, если monthly, и самим периодом (is_period),
+// и CTE active с подписками, активными хотя бы день месяца или периода. Цена подписки берётся
+// на последний активный день (ref_date) по правилам priceAt, с учётом фаз, приводится к месяцу
+// списаний и переводится в целевую валюту по курсу на этот день
+func statsCTE(filter domain.StatsFilter, monthly bool, args *queryArgs) string {
+	from := args.add(filter.StartPeriod)
+	to := args.add(filter.EndPeriod)
+	base := args.add(domain.BaseCurrency)
+	target := args.add(filter.TargetCurrency)
+
+	buckets := fmt.Sprintf("SELECT %s::date AS bucket_start, %s::date AS bucket_end, true AS is_period", from, to)
+	if monthly {
+		buckets = fmt.Sprintf(`SELECT
+					GREATEST(m::date, %[1]s::date) AS bucket_start,
+					LEAST((m + interval '1 month')::date - 1, %[2]s::date) AS bucket_end,
+					false AS is_period
+				FROM generate_series(date_trunc('month', %[1]s::date), %[2]s::date, interval '1 month') AS m
+				UNION ALL
+				%[3]s`, from, to, buckets)
+	}
+
+	conds := []string{"s.deleted_at IS NULL"}
+	if filter.ServiceID != nil {
+		conds = append(conds, "s.service_id = "+args.add(*filter.ServiceID))
+	}
+
+	return fmt.Sprintf(`
+			buckets AS (
+				%[1]s
+			),
+			active AS (
+				SELECT b.bucket_start, b.bucket_end, b.is_period,
+					s.id, s.user_id, s.service_id, s.start_date, s.end_date, s.currency, ref.date AS ref_date,
+					EXISTS (
+						SELECT 1 FROM subscription_pauses sp
+						WHERE sp.sub_id = s.id
+						AND sp.paused_from <= GREATEST(b.bucket_start, s.start_date)
+						AND (sp.paused_to IS NULL OR sp.paused_to >= ref.date)
+					) AS paused,
+					src.rate AS src_rate, tgt.rate AS tgt_rate,
+					price.price_minor * step.per_month * src.rate / tgt.rate
+						* power(10::numeric, %[2]s - %[3]s) AS monthly_price
+				FROM buckets b
+				JOIN subscriptions s
+					ON s.start_date <= b.bucket_end
+					AND (s.end_date IS NULL OR s.end_date >= b.bucket_start)
+				CROSS JOIN LATERAL (
+					SELECT LEAST(b.bucket_end, COALESCE(s.end_date, b.bucket_end)) AS date
+				) ref
+				CROSS JOIN LATERAL (SELECT %[7]s AS price_minor) price
+				CROSS JOIN LATERAL (
+					SELECT
+						CASE s.billing_period
+							WHEN 'weekly' THEN 365.25 / 7 / 12
+							WHEN 'quarterly' THEN 1 / 3.0
+							WHEN 'yearly' THEN 1 / 12.0
+							ELSE 1.0 / s.billing_interval
+						END AS per_month
+				) step
+				CROSS JOIN LATERAL (SELECT %[4]s AS rate) src
+				CROSS JOIN LATERAL (SELECT %[5]s AS rate) tgt
+				WHERE %[6]s
+			)`,
+		buckets,
+		minorUnitsExpr(target+"::text"), minorUnitsExpr("s.currency"),
+		rateAt("s.currency", "ref.date", base), rateAt(target+"::text", "ref.date", base),
+		strings.Join(conds, " AND "),
+		priceAt("ref.date"),
+	)
+}
+
+// statsRow принимает колонки statsColumns
+type statsRow struct {
+	stats         domain.SubStats
+	average       *int64
+	median        *int64
+	missing       []string
+	missingTarget bool
+}
+
+func (r *statsRow) dest() []interface{} {
+	return []interface{}{
+		&r.stats.ActiveSubscribers,
+		&r.stats.ActiveSubscriptions,
+		&r.stats.NewSubscriptions,
+		&r.stats.EndedSubscriptions,
+		&r.average,
+		&r.median,
+		&r.stats.AverageTenureMonths,
+		&r.missing,
+		&r.missingTarget,
+	}
+}
+
+// result переводит цены в Money целевой валюты и отмечает валюты без курса в missing
+func (r *statsRow) result(currency string, missing map[string]bool) domain.SubStats {
+	for _, code := range r.missing {
+		missing[code] = true
+	}
+	if r.missingTarget {
+		missing[currency] = true
+	}
+
+	stats := r.stats
+	if r.average != nil {
+		average := domain.NewMoney(*r.average, currency)
+		stats.AveragePrice = &average
+	}
+	if r.median != nil {
+		median := domain.NewMoney(*r.median, currency)
+		stats.MedianPrice = &median
+	}
+
+	return stats
+}
+
+// missingRatesError возвращает ошибку валидации, если для валют нет курса на нужные даты
+func missingRatesError(missing map[string]bool) error {
+	if len(missing) == 0 {
+		return nil
+	}
+
+	codes := make([]string, 0, len(missing))
+	for code := range missing {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	return domain.NewError(
+		domain.ErrValidation,
+		"exchange rate is missing for "+strings.Join(codes, ", "),
+		fmt.Errorf("no exchange rates for %v", codes),
+	)
+}
+
+// GetServiceStats возвращает статистику подписок на сервис каталога, заданный filter.ServiceID
+// или точным именем или псевдонимом filter.ServiceName, за период и по месяцам периода
+func (r *StatsRepository) GetServiceStats(ctx context.Context, filter domain.StatsFilter) (*domain.ServiceStats, error) {
+	conn, err := r.pg.GetConnection(ctx)
+	if err != nil {
+		return nil, wrapError(err, "statistics", "failed to get connection")
+	}
+	defer conn.Release()
+
+	var service domain.Service
+	if filter.ServiceID != nil {
+		query := `select ` + serviceColumns + `
+			from services
+			where id=$1
+		`
+		err = scanService(conn.QueryRow(ctx, query, *filter.ServiceID), &service)
+	} else {
+		query := `select ` + serviceColumns + `
+			from services
+			where ` + serviceNameCond("id", "$1")
+		err = scanService(conn.QueryRow(ctx, query, domain.NormalizeServiceName(*filter.ServiceName)), &service)
+	}
+	if err != nil {
+		return nil, wrapServiceError(err, "failed to get service for stats")
+	}
+
+	filter.ServiceID = &service.ID
+
+	var args queryArgs
+	query := fmt.Sprintf(`
+			WITH %s
+			SELECT b.bucket_start, b.is_period, %s
+			FROM buckets b
+			LEFT JOIN active a ON a.bucket_start = b.bucket_start AND a.is_period = b.is_period
+			GROUP BY b.bucket_start, b.is_period
+			ORDER BY b.is_period DESC, b.bucket_start
+		`,
+		statsCTE(filter, true, &args),
+		statsColumns,
+	)
+
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, wrapError(err, "statistics", "failed to calculate service stats")
+	}
+	defer rows.Close()
+
+	result := domain.ServiceStats{
+		Service:  &service,
+		Currency: filter.TargetCurrency,
+	}
+	missing := make(map[string]bool)
+
+	for rows.Next() {
+		var (
+			row      statsRow
+			month    time.Time
+			isPeriod bool
+		)
+		if err := rows.Scan(append([]interface{}{&month, &isPeriod}, row.dest()...)...); err != nil {
+			return nil, wrapError(err, "statistics", "failed to scan service stats")
+		}
+
+		stats := row.result(filter.TargetCurrency, missing)
+		if isPeriod {
+			result.Period = stats
+			continue
+		}
+		result.Months = append(result.Months, domain.MonthStats{
+			Month:    time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC),
+			SubStats: stats,
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err, "statistics", "rows error")
+	}
+
+	if err := missingRatesError(missing); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GetStatsSummary возвращает статистику подписок на все сервисы за период и по каждому сервису,
+// сервисы идут по убыванию числа активных подписчиков
+func (r *StatsRepository) GetStatsSummary(ctx context.Context, filter domain.StatsFilter) (*domain.StatsSummary, error) {
+	conn, err := r.pg.GetConnection(ctx)
+	if err != nil {
+		return nil, wrapError(err, "statistics", "failed to get connection")
+	}
+	defer conn.Release()
+
+	var args queryArgs
+	query := fmt.Sprintf(`
+			WITH %s
+			SELECT g.*, sv.name
+			FROM (
+				SELECT GROUPING(a.service_id) = 1 AS is_total, a.service_id, %s
+				FROM active a
+				GROUP BY GROUPING SETS ((a.service_id), ())
+			) g
+			LEFT JOIN services sv ON sv.id = g.service_id
+			ORDER BY g.is_total DESC, 3 DESC, sv.name
+		`,
+		statsCTE(filter, false, &args),
+		statsColumns,
+	)
+
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, wrapError(err, "statistics", "failed to calculate stats summary")
+	}
+	defer rows.Close()
+
+	summary := domain.StatsSummary{Currency: filter.TargetCurrency}
+	missing := make(map[string]bool)
+
+	for rows.Next() {
+		var (
+			row         statsRow
+			isTotal     bool
+			serviceID   *uuid.UUID
+			serviceName *string
+		)
+		dest := append([]interface{}{&isTotal, &serviceID}, row.dest()...)
+		if err := rows.Scan(append(dest, &serviceName)...); err != nil {
+			return nil, wrapError(err, "statistics", "failed to scan stats summary")
+		}
+
+		stats := row.result(filter.TargetCurrency, missing)
+		if isTotal {
+			summary.Total = stats
+			continue
+		}
+		if serviceID == nil || serviceName == nil {
+			continue
+		}
+		summary.Services = append(summary.Services, domain.ServiceStatsRow{
+			ServiceID:   *serviceID,
+			ServiceName: *serviceName,
+			SubStats:    stats,
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err, "statistics", "rows error")
+	}
+
+	if err := missingRatesError(missing); err != nil {
+		return nil, err
+	}
+
+	return &summary, nil
+}
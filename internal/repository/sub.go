@@ -4,13 +4,65 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/v5"
 	"github.com/maYkiss56/subscription-aggregation-service/internal/domain"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/utils"
 	"github.com/maYkiss56/subscription-aggregation-service/pkg/client/postgresql"
 )
 
+// currentPriceExpr возвращает регулярную цену подписки, действующую сегодня: последнюю запись истории цен,
+// начавшуюся не позже текущей даты, до первой записи действует её цена. Цены с будущих дат в ней не учитываются
+const currentPriceExpr = `COALESCE((
+		SELECT sp.price_minor FROM subscription_prices sp
+		WHERE sp.sub_id = subscriptions.id AND sp.effective_from <= CURRENT_DATE
+		ORDER BY sp.effective_from DESC
+		LIMIT 1
+	), (
+		SELECT sp.price_minor FROM subscription_prices sp
+		WHERE sp.sub_id = subscriptions.id
+		ORDER BY sp.effective_from
+		LIMIT 1
+	), subscriptions.price_minor)`
+
+// subColumns перечисляет колонки подписки в порядке, который ожидает scanSub,
+// фазы цены, история цен, паузы и метки собираются в JSON-массивы по порядку
+const subColumns = `id, service_name, service_id, category, ` + currentPriceExpr + ` AS price_minor, currency, user_id, start_date, end_date,
+	billing_period, billing_interval, billing_anchor, date_format, allow_overlap,
+	version, created_at, updated_at, deleted_at,
+	(
+		SELECT COALESCE(json_agg(json_build_object(
+			'kind', p.kind, 'price_minor', p.price_minor, 'months', p.months
+		) ORDER BY p.position), '[]')
+		FROM subscription_phases p
+		WHERE p.sub_id = subscriptions.id
+	) AS phases,
+	(
+		SELECT COALESCE(json_agg(json_build_object(
+			'effective_from', sp.effective_from, 'price_minor', sp.price_minor
+		) ORDER BY sp.effective_from), '[]')
+		FROM subscription_prices sp
+		WHERE sp.sub_id = subscriptions.id
+	) AS prices,
+	(
+		SELECT COALESCE(json_agg(json_build_object(
+			'paused_from', sp.paused_from, 'paused_to', sp.paused_to
+		) ORDER BY sp.paused_from), '[]')
+		FROM subscription_pauses sp
+		WHERE sp.sub_id = subscriptions.id
+	) AS pauses,
+	(
+		SELECT COALESCE(json_agg(t.name ORDER BY t.name), '[]')
+		FROM subscription_tags st
+		JOIN tags t ON t.id = st.tag_id
+		WHERE st.sub_id = subscriptions.id
+	) AS tags`
+
 type SubRepository struct {
 	pg *postgresql.PostgresClient
 }
@@ -19,219 +71,1112 @@ func New(pg *postgresql.PostgresClient) *SubRepository {
 	return &SubRepository{pg: pg}
 }
 
+// phaseRecord represents price phase in phases column
+type phaseRecord struct {
+	Kind       domain.PhaseKind `json:"kind"`
+	PriceMinor int64            `json:"price_minor"`
+	Months     int              `json:"months"`
+}
+
+// priceRecord represents price change in prices column
+type priceRecord struct {
+	EffectiveFrom string `json:"effective_from"`
+	PriceMinor    int64  `json:"price_minor"`
+}
+
+// pauseRecord represents pause in pauses column
+type pauseRecord struct {
+	PausedFrom string  `json:"paused_from"`
+	PausedTo   *string `json:"paused_to"`
+}
+
+func scanSub(row pgx.Row, sub *domain.Sub) error {
+	var (
+		phases []phaseRecord
+		prices []priceRecord
+		pauses []pauseRecord
+	)
+
+	err := row.Scan(
+		&sub.ID,
+		&sub.ServiceName,
+		&sub.ServiceID,
+		&sub.Category,
+		&sub.Price.Amount,
+		&sub.Price.Currency,
+		&sub.UserID,
+		&sub.StartDate,
+		&sub.EndDate,
+		&sub.Billing.Period,
+		&sub.Billing.Interval,
+		&sub.Billing.Anchor,
+		&sub.DateFormat,
+		&sub.AllowOverlap,
+		&sub.Version,
+		&sub.CreatedAt,
+		&sub.UpdatedAt,
+		&sub.DeletedAt,
+		&phases,
+		&prices,
+		&pauses,
+		&sub.Tags,
+	)
+	if err != nil {
+		return err
+	}
+
+	sub.Phases = make([]domain.PricePhase, len(phases))
+	for i, phase := range phases {
+		sub.Phases[i] = domain.PricePhase{
+			Kind:   phase.Kind,
+			Price:  domain.NewMoney(phase.PriceMinor, sub.Price.Currency),
+			Months: phase.Months,
+		}
+	}
+
+	sub.PriceHistory = make([]domain.PriceChange, len(prices))
+	for i, price := range prices {
+		effectiveFrom, err := utils.ParseDate(price.EffectiveFrom)
+		if err != nil {
+			return err
+		}
+
+		sub.PriceHistory[i] = domain.PriceChange{
+			EffectiveFrom: effectiveFrom,
+			Price:         domain.NewMoney(price.PriceMinor, sub.Price.Currency),
+		}
+	}
+
+	sub.Pauses = make([]domain.Pause, len(pauses))
+	for i, pause := range pauses {
+		from, err := utils.ParseDate(pause.PausedFrom)
+		if err != nil {
+			return err
+		}
+		sub.Pauses[i].From = from
+
+		if pause.PausedTo != nil {
+			to, err := utils.ParseDate(*pause.PausedTo)
+			if err != nil {
+				return err
+			}
+			sub.Pauses[i].To = &to
+		}
+	}
+
+	return nil
+}
+
+// replacePhases заменяет фазы цены подписки внутри транзакции
+func replacePhases(ctx context.Context, tx pgx.Tx, sub *domain.Sub) error {
+	if _, err := tx.Exec(ctx, `delete from subscription_phases where sub_id=$1`, sub.ID); err != nil {
+		return err
+	}
+
+	if len(sub.Phases) == 0 {
+		return nil
+	}
+
+	query := `
+		insert into subscription_phases (sub_id, position, kind, price_minor, months)
+		values ($1, $2, $3, $4, $5)
+	`
+
+	batch := &pgx.Batch{}
+	for i, phase := range sub.Phases {
+		batch.Queue(query, sub.ID, i, phase.Kind, phase.Price.Amount, phase.Months)
+	}
+
+	return tx.SendBatch(ctx, batch).Close()
+}
+
+// replaceTags заменяет метки подписки внутри транзакции, новые метки добавляются в справочник
+func replaceTags(ctx context.Context, tx pgx.Tx, sub *domain.Sub) error {
+	if _, err := tx.Exec(ctx, `delete from subscription_tags where sub_id=$1`, sub.ID); err != nil {
+		return err
+	}
+
+	if len(sub.Tags) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(`insert into tags (name) select unnest($1::text[]) on conflict (name) do nothing`, sub.Tags)
+	batch.Queue(`
+		insert into subscription_tags (sub_id, tag_id)
+		select $1, id from tags where name = any($2::text[])
+	`, sub.ID, sub.Tags)
+
+	return tx.SendBatch(ctx, batch).Close()
+}
+
+// inheritCategory задаёт подписке без категории категорию её сервиса каталога
+func inheritCategory(ctx context.Context, tx pgx.Tx, sub *domain.Sub) error {
+	if sub.Category != nil {
+		return nil
+	}
+
+	return tx.QueryRow(ctx, `select category from services where id=$1`, sub.ServiceID).Scan(&sub.Category)
+}
+
+// resolveService сопоставляет подписке сервис каталога внутри транзакции. Подписка без имени
+// получает имя заданного сервиса, подписка без сервиса находит его по точному нормализованному имени
+// или псевдониму, а если такого нет, имя подписки добавляется в каталог как новый сервис.
+// Похожие имена только предлагаются подсказками, чтобы "Netflix Premium" не сливался молча с "Netflix"
+func resolveService(ctx context.Context, tx pgx.Tx, sub *domain.Sub) error {
+	if sub.ServiceID != uuid.Nil {
+		var name string
+		err := tx.QueryRow(ctx, `select name from services where id=$1`, sub.ServiceID).Scan(&name)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.NewValidationError(domain.FieldError{Field: "service_id", Message: "service not found"})
+		}
+		if err != nil {
+			return wrapError(err, "service", "failed to get service")
+		}
+
+		if sub.ServiceName == "" {
+			sub.ServiceName = name
+		}
+		return nil
+	}
+
+	normalized := domain.NormalizeServiceName(sub.ServiceName)
+
+	// Параллельные запросы с одним и тем же новым именем добавляют сервис по очереди
+	_, err := tx.Exec(ctx, `select pg_advisory_xact_lock(hashtext($1))`, "service/"+normalized)
+	if err != nil {
+		return wrapError(err, "service", "failed to lock service name")
+	}
+
+	query := `
+		select service_id
+		from service_names
+		where normalized_name = $1
+	`
+
+	err = tx.QueryRow(ctx, query, normalized).Scan(&sub.ServiceID)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return wrapError(err, "service", "failed to find service")
+	}
+
+	sub.ServiceID = uuid.New()
+
+	batch := &pgx.Batch{}
+	batch.Queue(`insert into services (id, name) values ($1, $2)`, sub.ServiceID, sub.ServiceName)
+	batch.Queue(
+		`insert into service_names (normalized_name, service_id, name, is_alias) values ($1, $2, $3, false)`,
+		normalized, sub.ServiceID, sub.ServiceName,
+	)
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return wrapError(err, "service", "failed to add service to catalog")
+	}
+
+	return nil
+}
+
+// checkOverlap проверяет, что подписка не пересекается по датам с другими подписками
+// того же пользователя на тот же сервис, если пересечение не разрешено явно ни ей, ни другой подписке.
+// Проверки для одной пары пользователь-сервис выполняются по очереди под advisory lock
+// до конца транзакции, чтобы параллельные запросы не создали дубликаты
+func checkOverlap(ctx context.Context, tx pgx.Tx, sub *domain.Sub) error {
+	if sub.AllowOverlap {
+		return nil
+	}
+
+	_, err := tx.Exec(ctx, `select pg_advisory_xact_lock(hashtext($1))`, sub.UserID.String()+"/"+sub.ServiceID.String())
+	if err != nil {
+		return wrapError(err, "subscription", "failed to lock subscriptions")
+	}
+
+	query := `
+		select id
+		from subscriptions
+		where user_id=$1 and service_id=$2 and id<>$3 and deleted_at is null and not allow_overlap
+		and daterange(start_date, end_date, '[]') && daterange($4::date, $5::date, '[]')
+		order by start_date, id
+		limit 1
+	`
+
+	var conflictingID uuid.UUID
+	err = tx.QueryRow(ctx, query, sub.UserID, sub.ServiceID, sub.ID, sub.StartDate, sub.EndDate).Scan(&conflictingID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return wrapError(err, "subscription", "failed to check overlapping subscriptions")
+	}
+
+	return domain.NewOverlapError(conflictingID)
+}
+
+// overlapChanged сообщает, что у подписки изменились пользователь, сервис, даты или с неё снят
+// разрешение на пересечение
+func overlapChanged(before, after *domain.Sub) bool {
+	return before.UserID != after.UserID ||
+		before.ServiceID != after.ServiceID ||
+		!before.StartDate.Equal(after.StartDate) ||
+		!equalDates(before.EndDate, after.EndDate) ||
+		before.AllowOverlap && !after.AllowOverlap
+}
+
+func equalDates(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// replacePauses заменяет паузы подписки внутри транзакции
+func replacePauses(ctx context.Context, tx pgx.Tx, sub *domain.Sub) error {
+	if _, err := tx.Exec(ctx, `delete from subscription_pauses where sub_id=$1`, sub.ID); err != nil {
+		return err
+	}
+
+	if len(sub.Pauses) == 0 {
+		return nil
+	}
+
+	query := `
+		insert into subscription_pauses (sub_id, paused_from, paused_to)
+		values ($1, $2, $3)
+	`
+
+	batch := &pgx.Batch{}
+	for _, pause := range sub.Pauses {
+		batch.Queue(query, sub.ID, pause.From, pause.To)
+	}
+
+	return tx.SendBatch(ctx, batch).Close()
+}
+
+// replacePrices заменяет историю цен подписки внутри транзакции
+func replacePrices(ctx context.Context, tx pgx.Tx, sub *domain.Sub) error {
+	if _, err := tx.Exec(ctx, `delete from subscription_prices where sub_id=$1`, sub.ID); err != nil {
+		return err
+	}
+
+	query := `
+		insert into subscription_prices (sub_id, effective_from, price_minor)
+		values ($1, $2, $3)
+	`
+
+	batch := &pgx.Batch{}
+	for _, change := range sub.PriceHistory {
+		batch.Queue(query, sub.ID, change.EffectiveFrom, change.Price.Amount)
+	}
+
+	return tx.SendBatch(ctx, batch).Close()
+}
+
 func (r *SubRepository) CreateSub(ctx context.Context, sub *domain.Sub) (id uuid.UUID, err error) {
 	conn, err := r.pg.GetConnection(ctx)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("failed to get connection: %w", err)
+		return uuid.Nil, wrapError(err, "subscription", "failed to get connection")
 	}
 	defer conn.Release()
 
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, wrapError(err, "subscription", "failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	if err := resolveService(ctx, tx, sub); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := inheritCategory(ctx, tx, sub); err != nil {
+		return uuid.Nil, wrapError(err, "service", "failed to get service category")
+	}
+
+	if err := checkOverlap(ctx, tx, sub); err != nil {
+		return uuid.Nil, err
+	}
+
 	query := `
 		insert into subscriptions
-		(id, service_name, price, user_id, start_date, end_date)
-		values ($1, $2, $3, $4, $5, $6)
-		returning id
+		(id, service_name, service_id, category, price_minor, currency, user_id, start_date, end_date,
+		billing_period, billing_interval, billing_anchor, date_format, allow_overlap)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		returning id, version, created_at, updated_at
 	`
 
-	err = conn.QueryRow(
+	err = tx.QueryRow(
 		ctx, query,
 		sub.ID,
 		sub.ServiceName,
-		sub.Price,
+		sub.ServiceID,
+		sub.Category,
+		sub.Price.Amount,
+		sub.Price.Currency,
 		sub.UserID,
 		sub.StartDate,
 		sub.EndDate,
-	).Scan(&sub.ID)
+		sub.Billing.Period,
+		sub.Billing.Interval,
+		sub.Billing.Anchor,
+		sub.DateFormat,
+		sub.AllowOverlap,
+	).Scan(&sub.ID, &sub.Version, &sub.CreatedAt, &sub.UpdatedAt)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("failed to create subsciption: %w", err)
+		return uuid.Nil, wrapError(err, "subscription", "failed to create subsciption")
+	}
+
+	if err := replacePhases(ctx, tx, sub); err != nil {
+		return uuid.Nil, wrapError(err, "subscription", "failed to create price phases")
 	}
 
-	return sub.ID, err
+	if err := replacePrices(ctx, tx, sub); err != nil {
+		return uuid.Nil, wrapError(err, "subscription", "failed to create price history")
+	}
+
+	if err := replaceTags(ctx, tx, sub); err != nil {
+		return uuid.Nil, wrapError(err, "subscription", "failed to create tags")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return uuid.Nil, wrapError(err, "subscription", "failed to commit transaction")
+	}
+
+	return sub.ID, nil
+}
+
+func (r *SubRepository) GetAllSubs(ctx context.Context, params domain.ListSubsParams) (*domain.SubPage, error) {
+	return r.listSubs(ctx, params)
+}
+
+func (r *SubRepository) GetSubByUserID(ctx context.Context, userUID uuid.UUID, params domain.ListSubsParams) (*domain.SubPage, error) {
+	params.UserID = &userUID
+
+	return r.listSubs(ctx, params)
 }
 
-func (r *SubRepository) GetAllSubs(ctx context.Context) ([]*domain.Sub, error) {
+// sortColumns сопоставляет полю сортировки колонку или выражение и тип значения курсора
+var sortColumns = map[domain.SortField]struct {
+	column string
+	cast   string
+}{
+	domain.SortByStartDate:   {column: "start_date", cast: "date"},
+	domain.SortByPrice:       {column: currentPriceExpr, cast: "bigint"},
+	domain.SortByServiceName: {column: "service_name", cast: "text"},
+}
+
+// statusExpr возвращает выражение состояния подписки на дату, повторяющее domain.Sub.StatusAt
+func statusExpr(at string) string {
+	return fmt.Sprintf(`CASE
+			WHEN start_date > %[1]s::date THEN 'scheduled'
+			WHEN end_date < %[1]s::date THEN 'ended'
+			WHEN EXISTS (
+				SELECT 1 FROM subscription_pauses sp
+				WHERE sp.sub_id = subscriptions.id
+				AND %[1]s::date >= sp.paused_from
+				AND (sp.paused_to IS NULL OR %[1]s::date <= sp.paused_to)
+			) THEN 'paused'
+			WHEN end_date < %[1]s::date + %[2]d THEN 'ending_soon'
+			ELSE 'active'
+		END`, at, domain.EndingSoonDays)
+}
+
+func (r *SubRepository) listSubs(ctx context.Context, params domain.ListSubsParams) (*domain.SubPage, error) {
 	conn, err := r.pg.GetConnection(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed go get connection: %w", err)
+		return nil, wrapError(err, "subscription", "failed to get connection")
 	}
 	defer conn.Release()
 
-	query := `select
-		id, service_name,
-		price, user_id,
-		start_date, end_date
+	if params.Limit <= 0 || params.Limit > domain.MaxListLimit {
+		params.Limit = domain.DefaultListLimit
+	}
+
+	var (
+		conds []string
+		args  queryArgs
+	)
+
+	if !params.IncludeDeleted {
+		conds = append(conds, "deleted_at IS NULL")
+	}
+	if params.UserID != nil {
+		conds = append(conds, "user_id = "+args.add(*params.UserID))
+	}
+	if params.ServiceName != nil {
+		conds = append(conds, serviceNameCond("service_id", args.add(domain.NormalizeServiceName(*params.ServiceName))))
+	}
+	if params.ServiceID != nil {
+		conds = append(conds, "service_id = "+args.add(*params.ServiceID))
+	}
+	if params.Category != nil {
+		conds = append(conds, "category = "+args.add(*params.Category))
+	}
+	if len(params.Tags) > 0 {
+		conds = append(conds, tagsCond("subscriptions.id", args.add(params.Tags)))
+	}
+	// Границы цены заданы в основных единицах и переводятся в минимальные единицы валюты подписки,
+	// сравниваются с ценой, действующей сегодня
+	if params.MinPrice != nil {
+		conds = append(conds, fmt.Sprintf("%s >= %s::numeric * power(10, %s)", currentPriceExpr, args.add(*params.MinPrice), minorUnitsExpr("currency")))
+	}
+	if params.MaxPrice != nil {
+		conds = append(conds, fmt.Sprintf("%s <= %s::numeric * power(10, %s)", currentPriceExpr, args.add(*params.MaxPrice), minorUnitsExpr("currency")))
+	}
+	if params.ActiveFrom != nil && params.ActiveTo != nil {
+		conds = append(conds, fmt.Sprintf(
+			"start_date <= %s::date AND (end_date >= %s::date OR end_date IS NULL)",
+			args.add(*params.ActiveTo), args.add(*params.ActiveFrom),
+		))
+	}
+	if params.Ended != nil {
+		if *params.Ended {
+			conds = append(conds, "end_date < CURRENT_DATE")
+		} else {
+			conds = append(conds, "(end_date >= CURRENT_DATE OR end_date IS NULL)")
+		}
+	}
+
+	if len(params.Statuses) > 0 {
+		statuses := make([]string, len(params.Statuses))
+		for i, status := range params.Statuses {
+			statuses[i] = string(status)
+		}
+
+		conds = append(conds, fmt.Sprintf(
+			"%s = ANY(%s::text[])",
+			statusExpr(args.add(params.StatusAt)), args.add(statuses),
+		))
+	}
+
+	sortColumn, ok := sortColumns[params.Sort]
+	if !ok {
+		sortColumn = sortColumns[domain.SortByStartDate]
+	}
+
+	direction, op := "ASC", ">"
+	if params.Desc {
+		direction, op = "DESC", "<"
+	}
+
+	if params.Cursor != nil {
+		conds = append(conds, fmt.Sprintf(
+			"(%s, id) %s (%s::text::%s, %s)",
+			sortColumn.column, op, args.add(params.Cursor.Value), sortColumn.cast, args.add(params.Cursor.ID),
+		))
+	}
+
+	query := `select ` + subColumns + `
 		from subscriptions
 	`
+	if len(conds) > 0 {
+		query += " where " + strings.Join(conds, " and ")
+	}
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	query += fmt.Sprintf(
+		" order by %s %s, id %s limit %s",
+		sortColumn.column, direction, direction, args.add(params.Limit+1),
+	)
 
-	rows, err := conn.Query(ctx, query)
+	rows, err := conn.Query(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query subs: %w", err)
+		return nil, wrapError(err, "subscription", "failed to query subs")
 	}
 	defer rows.Close()
 
 	var subs []*domain.Sub
 	for rows.Next() {
 		var sub domain.Sub
-		err := rows.Scan(
-			&sub.ID,
-			&sub.ServiceName,
-			&sub.Price,
-			&sub.UserID,
-			&sub.StartDate,
-			&sub.EndDate,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to san row subs: %w", err)
+		if err := scanSub(rows, &sub); err != nil {
+			return nil, wrapError(err, "subscription", "failed to scan row subs")
 		}
 		subs = append(subs, &sub)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows error: %w", err)
+		return nil, wrapError(err, "subscription", "rows error")
+	}
+
+	page := &domain.SubPage{Subs: subs}
+	if len(subs) > params.Limit {
+		page.Subs = subs[:params.Limit]
+		page.NextCursor = cursorAfter(page.Subs[params.Limit-1], params)
+	}
+
+	return page, nil
+}
+
+// cursorAfter строит курсор, указывающий на последнюю подписку страницы
+func cursorAfter(sub *domain.Sub, params domain.ListSubsParams) *domain.Cursor {
+	cursor := &domain.Cursor{
+		Sort: params.Sort,
+		Desc: params.Desc,
+		ID:   sub.ID,
 	}
 
-	return subs, nil
+	switch params.Sort {
+	case domain.SortByPrice:
+		cursor.Value = strconv.FormatInt(sub.Price.Amount, 10)
+	case domain.SortByServiceName:
+		cursor.Value = sub.ServiceName
+	default:
+		cursor.Sort = domain.SortByStartDate
+		cursor.Value = utils.ToDateString(sub.StartDate)
+	}
+
+	return cursor
 }
 
-func (r *SubRepository) GetSubByUserID(ctx context.Context, userUID uuid.UUID) ([]*domain.Sub, error) {
+func (r *SubRepository) GetSubByID(ctx context.Context, id uuid.UUID, includeDeleted bool) (*domain.Sub, error) {
 	conn, err := r.pg.GetConnection(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get connection: %w", err)
+		return nil, wrapError(err, "subscription", "failed to get connection")
 	}
 	defer conn.Release()
 
-	query := `select
-		id, service_name,
-		price, user_id,
-		start_date, end_date
+	query := `select ` + subColumns + `
 		from subscriptions
-		where user_id=$1
+		where id=$1 and ($2 or deleted_at is null)
 	`
 
-	rows, err := conn.Query(ctx, query, userUID)
+	var sub domain.Sub
+	err = scanSub(conn.QueryRow(ctx, query, id, includeDeleted), &sub)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get subs by id: %w", err)
+		return nil, wrapError(err, "subscription", "failed to get sub by id")
 	}
-	defer rows.Close()
 
-	var subs []*domain.Sub
-	for rows.Next() {
-		var sub domain.Sub
-		err := rows.Scan(
-			&sub.ID,
-			&sub.ServiceName,
-			&sub.Price,
-			&sub.UserID,
-			&sub.StartDate,
-			&sub.EndDate,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan subs: %w", err)
+	return &sub, nil
+}
+
+// UpdateSub блокирует подписку, применяет к ней update и сохраняет результат в одной транзакции
+func (r *SubRepository) UpdateSub(ctx context.Context, id uuid.UUID, update func(sub *domain.Sub) error) (*domain.Sub, error) {
+	conn, err := r.pg.GetConnection(ctx)
+	if err != nil {
+		return nil, wrapError(err, "subscription", "failed to get connection")
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, wrapError(err, "subscription", "failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	selectQuery := `select ` + subColumns + `
+		from subscriptions
+		where id=$1 and deleted_at is null
+		for update
+	`
+
+	var sub domain.Sub
+	err = scanSub(tx.QueryRow(ctx, selectQuery, id), &sub)
+	if err != nil {
+		return nil, wrapError(err, "subscription", "failed to get subscription for update")
+	}
+	before := sub
+
+	if err := update(&sub); err != nil {
+		return nil, err
+	}
+
+	if err := resolveService(ctx, tx, &sub); err != nil {
+		return nil, err
+	}
+
+	// Пересечение проверяется, только если изменилось то, от чего оно зависит, иначе уже
+	// существующие пересечения запрещали бы паузы и смену цены
+	if overlapChanged(&before, &sub) {
+		if err := checkOverlap(ctx, tx, &sub); err != nil {
+			return nil, err
 		}
-		subs = append(subs, &sub)
 	}
 
-	return subs, nil
+	updateQuery := `
+			UPDATE subscriptions
+			SET
+				service_name = $1,
+				service_id = $2,
+				category = $3,
+				price_minor = $4,
+				currency = $5,
+				start_date = $6,
+				end_date = $7,
+				billing_period = $8,
+				billing_interval = $9,
+				billing_anchor = $10,
+				date_format = $11,
+				allow_overlap = $12,
+				version = version + 1,
+				updated_at = now()
+			WHERE id = $13
+			RETURNING version, updated_at
+		`
+
+	err = tx.QueryRow(ctx, updateQuery,
+		sub.ServiceName,
+		sub.ServiceID,
+		sub.Category,
+		sub.Price.Amount,
+		sub.Price.Currency,
+		sub.StartDate,
+		sub.EndDate,
+		sub.Billing.Period,
+		sub.Billing.Interval,
+		sub.Billing.Anchor,
+		sub.DateFormat,
+		sub.AllowOverlap,
+		id,
+	).Scan(&sub.Version, &sub.UpdatedAt)
+	if err != nil {
+		return nil, wrapError(err, "subscription", "failed to update subscription")
+	}
+
+	if err := replacePhases(ctx, tx, &sub); err != nil {
+		return nil, wrapError(err, "subscription", "failed to update price phases")
+	}
+
+	if err := replacePrices(ctx, tx, &sub); err != nil {
+		return nil, wrapError(err, "subscription", "failed to update price history")
+	}
+
+	if err := replacePauses(ctx, tx, &sub); err != nil {
+		return nil, wrapError(err, "subscription", "failed to update pauses")
+	}
+
+	if err := replaceTags(ctx, tx, &sub); err != nil {
+		return nil, wrapError(err, "subscription", "failed to update tags")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, wrapError(err, "subscription", "failed to commit transaction")
+	}
+
+	return &sub, nil
 }
 
-func (r *SubRepository) UpdateSub(ctx context.Context, id uuid.UUID, req *domain.UpdateSubRequest) (*domain.Sub, error) {
+// DeleteSub помечает подписку удалённой, если versions не пуст, только при совпадении её версии с одной из них
+func (r *SubRepository) DeleteSub(ctx context.Context, id uuid.UUID, versions []int) error {
 	conn, err := r.pg.GetConnection(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get connection: %w", err)
+		return wrapError(err, "subscription", "failed to get connection")
 	}
 	defer conn.Release()
 
 	query := `
 			UPDATE subscriptions
 			SET
-				service_name = COALESCE($1, service_name),
-				price = COALESCE($2, price),
-				start_date = COALESCE($3, start_date),
-				end_date = $4
-			WHERE id = $5
-			RETURNING id, service_name, price, user_id, start_date, end_date
+				deleted_at = now(),
+				updated_at = now(),
+				version = version + 1
+			WHERE id = $1
+			AND deleted_at IS NULL
+			AND (cardinality($2::int[]) = 0 OR version = ANY($2))
 		`
 
-	var sub domain.Sub
-	err = conn.QueryRow(ctx, query,
-		req.ServiceName,
-		req.Price,
-		req.StartDate,
-		req.EndDate,
-		id,
-	).Scan(
-		&sub.ID,
-		&sub.ServiceName,
-		&sub.Price,
-		&sub.UserID,
-		&sub.StartDate,
-		&sub.EndDate,
-	)
+	if versions == nil {
+		versions = []int{}
+	}
 
+	cmd, err := conn.Exec(ctx, query, id, versions)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("error not found: %w", err)
+		return wrapError(err, "subscription", "failed to delete subscription")
+	}
+
+	if cmd.RowsAffected() == 0 {
+		var exists bool
+		err := conn.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM subscriptions WHERE id = $1 AND deleted_at IS NULL)`, id).Scan(&exists)
+		if err != nil {
+			return wrapError(err, "subscription", "failed to check subscription")
 		}
-		return nil, fmt.Errorf("failed to update subscription: %w", err)
+
+		if exists {
+			return domain.NewError(domain.ErrPreconditionFailed, "subscription version does not match", fmt.Errorf("subscription %s", id))
+		}
+
+		return domain.NewError(domain.ErrNotFound, "subscription not found", fmt.Errorf("subscription %s", id))
 	}
 
-	return &sub, nil
+	return nil
 }
 
-func (r *SubRepository) DeleteSub(ctx context.Context, id uuid.UUID) error {
+// RestoreSub снимает с подписки пометку об удалении, если она не пересекается с подписками,
+// созданными за время удаления
+func (r *SubRepository) RestoreSub(ctx context.Context, id uuid.UUID) (*domain.Sub, error) {
 	conn, err := r.pg.GetConnection(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get connection: %w", err)
+		return nil, wrapError(err, "subscription", "failed to get connection")
 	}
 	defer conn.Release()
 
-	query := `DELETE FROM subscriptions WHERE id = $1`
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, wrapError(err, "subscription", "failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+			UPDATE subscriptions
+			SET
+				deleted_at = NULL,
+				updated_at = now(),
+				version = version + 1
+			WHERE id = $1
+			AND deleted_at IS NOT NULL
+			RETURNING ` + subColumns
+
+	var sub domain.Sub
+	err = scanSub(tx.QueryRow(ctx, query, id), &sub)
+	if err == nil {
+		if err := checkOverlap(ctx, tx, &sub); err != nil {
+			return nil, err
+		}
 
-	cmd, err := conn.Exec(ctx, query, id)
+		if err := tx.Commit(ctx); err != nil {
+			return nil, wrapError(err, "subscription", "failed to commit transaction")
+		}
+
+		return &sub, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, wrapError(err, "subscription", "failed to restore subscription")
+	}
+
+	var exists bool
+	err = tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM subscriptions WHERE id = $1)`, id).Scan(&exists)
 	if err != nil {
-		return fmt.Errorf("failed to delete subscription: %w", err)
+		return nil, wrapError(err, "subscription", "failed to check subscription")
 	}
 
-	if cmd.RowsAffected() == 0 {
-		return fmt.Errorf("error not found: %w", err)
+	if exists {
+		return nil, domain.NewError(domain.ErrConflict, "subscription is not deleted", fmt.Errorf("subscription %s", id))
+	}
 
+	return nil, domain.NewError(domain.ErrNotFound, "subscription not found", fmt.Errorf("subscription %s", id))
+}
+
+// PurgeDeletedSubs окончательно удаляет подписки, помеченные удалёнными раньше before
+func (r *SubRepository) PurgeDeletedSubs(ctx context.Context, before time.Time) (int64, error) {
+	conn, err := r.pg.GetConnection(ctx)
+	if err != nil {
+		return 0, wrapError(err, "subscription", "failed to get connection")
 	}
+	defer conn.Release()
 
-	return nil
+	query := `DELETE FROM subscriptions WHERE deleted_at IS NOT NULL AND deleted_at < $1`
+
+	cmd, err := conn.Exec(ctx, query, before)
+	if err != nil {
+		return 0, wrapError(err, "subscription", "failed to purge subscriptions")
+	}
+
+	return cmd.RowsAffected(), nil
+}
+
+type currencySubtotal struct {
+	cost      int64
+	converted int64
 }
 
-func (r *SubRepository) CalculateTotalCost(ctx context.Context, filter domain.TotalCostFilter) (int, error) {
+// CalculateTotalCost суммирует списания подписок в периоде с разбивкой по месяцам и валютам.
+// Каждое списание переводится в целевую валюту по курсу на дату списания и округляется
+// до минимальных единиц целевой валюты половиной от нуля, суммы складываются без округления
+func (r *SubRepository) CalculateTotalCost(ctx context.Context, filter domain.TotalCostFilter) (*domain.TotalCost, error) {
 	conn, err := r.pg.GetConnection(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get connection: %w", err)
+		return nil, wrapError(err, "subscription", "failed to get connection")
 	}
 	defer conn.Release()
 
-	query := `
-			SELECT COALESCE(SUM(price), 0)
-			FROM subscriptions
-			WHERE start_date <= $1
-			AND (end_date >= $2 OR end_date IS NULL)
-		`
+	// Итог, группы и строки считаются отдельными запросами, поэтому читают один снимок данных
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, wrapError(err, "subscription", "failed to begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	var args queryArgs
+	converted := convertedCTE(filter, &args)
 
-	args := []interface{}{filter.EndPeriod, filter.StartPeriod}
-	argPos := 3
+	query := fmt.Sprintf(`
+			WITH months AS (
+				SELECT generate_series(
+					date_trunc('month', $1::date),
+					date_trunc('month', $2::date),
+					interval '1 month'
+				)::date AS month
+			),
+			%s
+			SELECT m.month, c.currency,
+				COALESCE(SUM(c.amount_minor), 0)::bigint,
+				COALESCE(SUM(c.amount), 0)::bigint,
+				COUNT(c.amount_minor) - COUNT(c.src_rate),
+				COUNT(c.amount_minor) - COUNT(c.tgt_rate)
+			FROM months m
+			LEFT JOIN converted c
+				ON c.charge_date >= m.month
+				AND c.charge_date < m.month + interval '1 month'
+			GROUP BY m.month, c.currency
+			ORDER BY m.month, c.currency
+		`,
+		converted,
+	)
 
-	if filter.UserID != nil {
-		query += fmt.Sprintf(" AND user_id = $%d", argPos)
-		args = append(args, *filter.UserID)
-		argPos++
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, wrapError(err, "subscription", "failed to calculate total cost")
 	}
-	if filter.ServiceName != nil {
-		query += fmt.Sprintf(" AND service_name = $%d", argPos)
-		args = append(args, *filter.ServiceName)
+	defer rows.Close()
+
+	var (
+		months     []time.Time
+		monthSums  = make(map[time.Time]int64)
+		currencies []string
+		subtotals  = make(map[string]*currencySubtotal)
+		missing    = make(map[string]bool)
+	)
+
+	for rows.Next() {
+		var (
+			month                        time.Time
+			currency                     *string
+			cost, amount                 int64
+			missingSource, missingTarget int
+		)
+		if err := rows.Scan(&month, &currency, &cost, &amount, &missingSource, &missingTarget); err != nil {
+			return nil, wrapError(err, "subscription", "failed to scan month cost")
+		}
+
+		if _, ok := monthSums[month]; !ok {
+			months = append(months, month)
+		}
+		monthSums[month] += amount
+
+		if currency == nil {
+			continue
+		}
+
+		if missingSource > 0 {
+			missing[*currency] = true
+		}
+		if missingTarget > 0 {
+			missing[filter.TargetCurrency] = true
+		}
+
+		subtotal, ok := subtotals[*currency]
+		if !ok {
+			subtotal = &currencySubtotal{}
+			subtotals[*currency] = subtotal
+			currencies = append(currencies, *currency)
+		}
+		subtotal.cost += cost
+		subtotal.converted += amount
 	}
 
-	var total int
-	err = conn.QueryRow(ctx, query, args...).Scan(&total)
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err, "subscription", "rows error")
+	}
+
+	if err := missingRatesError(missing); err != nil {
+		return nil, err
+	}
+
+	total := domain.TotalCost{
+		Currency: filter.TargetCurrency,
+		Total:    domain.NewMoney(0, filter.TargetCurrency),
+	}
+	for _, month := range months {
+		cost := domain.NewMoney(monthSums[month], filter.TargetCurrency)
+		total.Total = total.Total.Add(cost)
+		total.Months = append(total.Months, domain.MonthCost{Month: month, Cost: cost})
+	}
+
+	sort.Strings(currencies)
+	for _, currency := range currencies {
+		total.Currencies = append(total.Currencies, domain.CurrencyCost{
+			Currency:  currency,
+			Cost:      domain.NewMoney(subtotals[currency].cost, currency),
+			Converted: domain.NewMoney(subtotals[currency].converted, filter.TargetCurrency),
+		})
+	}
+
+	if filter.BreakdownBy != "" {
+		total.Groups, err = costGroups(ctx, tx, filter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(filter.GroupBy) > 0 {
+		total.GroupBy = filter.GroupBy
+		total.Rows, err = costRows(ctx, tx, filter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, wrapError(err, "subscription", "failed to commit transaction")
+	}
+
+	return &total, nil
+}
+
+// costGroups суммирует переведённые в целевую валюту списания по категориям или меткам подписок,
+// группы идут по убыванию суммы, подписки без категории или меток попадают в группу с ключом nil
+func costGroups(ctx context.Context, tx pgx.Tx, filter domain.TotalCostFilter) ([]domain.GroupCost, error) {
+	var args queryArgs
+	converted := convertedCTE(filter, &args)
+
+	key, join := "c.category", ""
+	if filter.BreakdownBy == domain.BreakdownByTag {
+		key = "t.name"
+		join = `
+			LEFT JOIN subscription_tags st ON st.sub_id = c.id
+			LEFT JOIN tags t ON t.id = st.tag_id`
+	}
+
+	query := fmt.Sprintf(`
+			WITH %[1]s
+			SELECT %[2]s, SUM(c.amount)::bigint, COUNT(DISTINCT c.id)
+			FROM converted c%[3]s
+			GROUP BY %[2]s
+			ORDER BY 2 DESC, 1 NULLS LAST
+		`,
+		converted, key, join,
+	)
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, wrapError(err, "subscription", "failed to calculate cost groups")
+	}
+	defer rows.Close()
+
+	var groups []domain.GroupCost
+	for rows.Next() {
+		var (
+			group  domain.GroupCost
+			amount int64
+		)
+		if err := rows.Scan(&group.Key, &amount, &group.Subscriptions); err != nil {
+			return nil, wrapError(err, "subscription", "failed to scan cost group")
+		}
+		group.Cost = domain.NewMoney(amount, filter.TargetCurrency)
+		groups = append(groups, group)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err, "subscription", "rows error")
+	}
+
+	return groups, nil
+}
+
+// costGroupColumns сопоставляет ключу группировки строк итогов выражения выборки и группировки.
+// Сервис группируется по сервису каталога, а не по имени подписки
+var costGroupColumns = map[domain.CostGroupKey]struct {
+	selects []string
+	groups  []string
+}{
+	domain.GroupByUserID: {
+		selects: []string{"c.user_id AS user_id"},
+		groups:  []string{"c.user_id"},
+	},
+	domain.GroupByServiceName: {
+		selects: []string{"c.service_id AS service_id", "sv.name AS service_name"},
+		groups:  []string{"c.service_id", "sv.name"},
+	},
+	domain.GroupByCategory: {
+		selects: []string{"c.category AS category"},
+		groups:  []string{"c.category"},
+	},
+	domain.GroupByMonth: {
+		selects: []string{"date_trunc('month', c.charge_date)::date AS month"},
+		groups:  []string{"date_trunc('month', c.charge_date)::date"},
+	},
+}
+
+// costRows суммирует переведённые в целевую валюту списания по сочетанию ключей группировки одним
+// запросом, сортирует строки по filter.OrderBy, а при равенстве по ключам, и оставляет первые filter.Limit
+func costRows(ctx context.Context, tx pgx.Tx, filter domain.TotalCostFilter) ([]domain.CostRow, error) {
+	var args queryArgs
+	converted := convertedCTE(filter, &args)
+
+	var selects, groups, keyOrder []string
+	for _, key := range filter.GroupBy {
+		columns := costGroupColumns[key]
+		selects = append(selects, columns.selects...)
+		groups = append(groups, columns.groups...)
+		keyOrder = append(keyOrder, string(key))
+	}
+
+	// Сортировка и число строк проверены при разборе запроса, здесь они только переводятся в SQL
+	orderBy := "total"
+	switch {
+	case filter.OrderBy == domain.CostOrderByCount:
+		orderBy = "subs_count"
+	case domain.CostGroupKey(filter.OrderBy).Valid():
+		orderBy = filter.OrderBy
+	}
+
+	direction := "DESC"
+	if filter.Order == "asc" {
+		direction = "ASC"
+	}
+	order := append([]string{orderBy + " " + direction}, keyOrder...)
+
+	query := fmt.Sprintf(`
+			WITH %s
+			SELECT %s, SUM(c.amount)::bigint AS total, COUNT(DISTINCT c.id) AS subs_count
+			FROM converted c
+			JOIN services sv ON sv.id = c.service_id
+			GROUP BY %s
+			ORDER BY %s
+			LIMIT %s
+		`,
+		converted,
+		strings.Join(selects, ", "),
+		strings.Join(groups, ", "),
+		strings.Join(order, ", "),
+		args.add(filter.Limit),
+	)
+
+	rows, err := tx.Query(ctx, query, args...)
 	if err != nil {
-		return 0, fmt.Errorf("failed to calculate total cost: %w", err)
+		return nil, wrapError(err, "subscription", "failed to calculate cost rows")
+	}
+	defer rows.Close()
+
+	var result []domain.CostRow
+	for rows.Next() {
+		var (
+			row    domain.CostRow
+			amount int64
+			dest   []interface{}
+		)
+		for _, key := range filter.GroupBy {
+			switch key {
+			case domain.GroupByUserID:
+				dest = append(dest, &row.UserID)
+			case domain.GroupByServiceName:
+				dest = append(dest, &row.ServiceID, &row.ServiceName)
+			case domain.GroupByCategory:
+				dest = append(dest, &row.Category)
+			case domain.GroupByMonth:
+				dest = append(dest, &row.Month)
+			}
+		}
+		dest = append(dest, &amount, &row.Count)
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, wrapError(err, "subscription", "failed to scan cost row")
+		}
+		row.Total = domain.NewMoney(amount, filter.TargetCurrency)
+		result = append(result, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, wrapError(err, "subscription", "rows error")
 	}
 
-	return total, nil
+	return result, nil
 }
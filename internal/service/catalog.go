@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/domain"
+)
+
+type ServiceRepository interface {
+	CreateService(ctx context.Context, service *domain.Service) (*domain.Service, error)
+	GetServices(ctx context.Context) ([]*domain.Service, error)
+	GetServiceByID(ctx context.Context, id uuid.UUID) (*domain.Service, error)
+	UpdateService(ctx context.Context, id uuid.UUID, update func(service *domain.Service) error) (*domain.Service, error)
+	DeleteService(ctx context.Context, id uuid.UUID) error
+	SuggestServices(ctx context.Context, query string, limit int) ([]domain.ServiceSuggestion, error)
+	RenormalizeServiceNames(ctx context.Context) (renamed, merged int, err error)
+}
+
+// CatalogService manages the catalog of services subscriptions refer to
+type CatalogService struct {
+	repo ServiceRepository
+}
+
+func NewCatalogService(repo ServiceRepository) *CatalogService {
+	return &CatalogService{
+		repo: repo,
+	}
+}
+
+func (s *CatalogService) CreateService(ctx context.Context, service *domain.Service) (*domain.Service, error) {
+	created, err := s.repo.CreateService(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (s *CatalogService) GetServices(ctx context.Context) ([]*domain.Service, error) {
+	services, err := s.repo.GetServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return services, nil
+}
+
+func (s *CatalogService) GetServiceByID(ctx context.Context, id uuid.UUID) (*domain.Service, error) {
+	service, err := s.repo.GetServiceByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return service, nil
+}
+
+func (s *CatalogService) UpdateService(ctx context.Context, id uuid.UUID, req *domain.UpdateServiceRequest) (*domain.Service, error) {
+	service, err := s.repo.UpdateService(ctx, id, func(service *domain.Service) error {
+		return service.Apply(req)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return service, nil
+}
+
+// DeleteService удаляет сервис из каталога, сервис с подписками удалить нельзя
+func (s *CatalogService) DeleteService(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.DeleteService(ctx, id); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SuggestServices подбирает сервисы каталога по началу или похожему написанию имени для автодополнения
+func (s *CatalogService) SuggestServices(ctx context.Context, query string, limit int) ([]domain.ServiceSuggestion, error) {
+	suggestions, err := s.repo.SuggestServices(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return suggestions, nil
+}
+
+// RenormalizeServiceNames приводит сохранённые имена и псевдонимы сервисов к текущим правилам нормализации
+// и сливает сервисы, имена которых стали совпадать
+func (s *CatalogService) RenormalizeServiceNames(ctx context.Context) (renamed, merged int, err error) {
+	renamed, merged, err = s.repo.RenormalizeServiceNames(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return renamed, merged, nil
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/maYkiss56/subscription-aggregation-service/internal/domain"
+	"github.com/maYkiss56/subscription-aggregation-service/internal/utils"
+)
+
+type RateRepository interface {
+	UpsertRates(ctx context.Context, rates []domain.ExchangeRate) (int, error)
+	GetRates(ctx context.Context, currency string) ([]domain.ExchangeRate, error)
+}
+
+type RateService struct {
+	repo RateRepository
+}
+
+func NewRateService(repo RateRepository) *RateService {
+	return &RateService{
+		repo: repo,
+	}
+}
+
+func (s *RateService) UpsertRates(ctx context.Context, rates []domain.ExchangeRate) (int, error) {
+	for i := range rates {
+		rates[i].Currency = strings.ToUpper(strings.TrimSpace(rates[i].Currency))
+		if err := rates[i].Validate(); err != nil {
+			return 0, domain.NewError(domain.ErrValidation, fmt.Sprintf("invalid exchange rate #%d", i+1), err)
+		}
+	}
+
+	loaded, err := s.repo.UpsertRates(ctx, rates)
+	if err != nil {
+		return 0, err
+	}
+
+	return loaded, nil
+}
+
+func (s *RateService) GetRates(ctx context.Context, currency string) ([]domain.ExchangeRate, error) {
+	rates, err := s.repo.GetRates(ctx, strings.ToUpper(currency))
+	if err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+// LoadRates разбирает курсы валют в указанном формате и сохраняет их
+func (s *RateService) LoadRates(ctx context.Context, r io.Reader, format domain.RatesFormat) (int, error) {
+	var (
+		rates []domain.ExchangeRate
+		err   error
+	)
+
+	switch format {
+	case domain.RatesCSV:
+		rates, err = ParseRatesCSV(r)
+	case domain.RatesJSON:
+		rates, err = ParseRatesJSON(r)
+	default:
+		return 0, fmt.Errorf("unsupported rates format: %s", format)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return s.UpsertRates(ctx, rates)
+}
+
+// LoadRatesFile загружает курсы валют из CSV или JSON файла, формат определяется по расширению
+func (s *RateService) LoadRatesFile(ctx context.Context, path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open rates file: %w", err)
+	}
+	defer file.Close()
+
+	format := domain.RatesFormat(strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), "."))
+
+	return s.LoadRates(ctx, file, format)
+}
+
+// ParseRatesCSV разбирает курсы валют из CSV с колонками currency,date,rate и строкой заголовка,
+// дата в формате YYYY-MM-DD
+func ParseRatesCSV(r io.Reader) ([]domain.ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, domain.NewError(domain.ErrValidation, "invalid rates csv", err)
+	}
+
+	if len(records) > 0 && strings.EqualFold(records[0][0], "currency") {
+		records = records[1:]
+	}
+
+	rates := make([]domain.ExchangeRate, 0, len(records))
+	for i, record := range records {
+		rate, err := parseRate(record[0], record[1], record[2])
+		if err != nil {
+			return nil, domain.NewError(domain.ErrValidation, fmt.Sprintf("invalid rates csv record #%d", i+1), err)
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+// ParseRatesJSON разбирает курсы валют из JSON массива объектов domain.RateRequest
+func ParseRatesJSON(r io.Reader) ([]domain.ExchangeRate, error) {
+	var records []domain.RateRequest
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, domain.NewError(domain.ErrValidation, "invalid rates json", err)
+	}
+
+	rates := make([]domain.ExchangeRate, 0, len(records))
+	for i, record := range records {
+		rate, err := parseRate(record.Currency, record.Date, strconv.FormatFloat(record.Rate, 'f', -1, 64))
+		if err != nil {
+			return nil, domain.NewError(domain.ErrValidation, fmt.Sprintf("invalid rates json record #%d", i+1), err)
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+func parseRate(currency, date, value string) (domain.ExchangeRate, error) {
+	rateDate, err := utils.ParseDate(strings.TrimSpace(date))
+	if err != nil {
+		return domain.ExchangeRate{}, errors.New("date must be in YYYY-MM-DD format")
+	}
+
+	rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return domain.ExchangeRate{}, errors.New("rate must be a number")
+	}
+
+	return domain.ExchangeRate{
+		Currency: strings.ToUpper(strings.TrimSpace(currency)),
+		Date:     rateDate,
+		Rate:     rate,
+	}, nil
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+
+	"github.com/maYkiss56/subscription-aggregation-service/internal/domain"
+)
+
+type StatsRepository interface {
+	GetServiceStats(ctx context.Context, filter domain.StatsFilter) (*domain.ServiceStats, error)
+	GetStatsSummary(ctx context.Context, filter domain.StatsFilter) (*domain.StatsSummary, error)
+}
+
+// StatsService computes statistics of subscriptions over a period
+type StatsService struct {
+	repo StatsRepository
+}
+
+func NewStatsService(repo StatsRepository) *StatsService {
+	return &StatsService{
+		repo: repo,
+	}
+}
+
+// GetServiceStats возвращает статистику подписок на сервис каталога за период и по месяцам периода
+func (s *StatsService) GetServiceStats(ctx context.Context, filter domain.StatsFilter) (*domain.ServiceStats, error) {
+	stats, err := s.repo.GetServiceStats(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// GetStatsSummary возвращает статистику подписок на все сервисы за период и по каждому сервису
+func (s *StatsService) GetStatsSummary(ctx context.Context, filter domain.StatsFilter) (*domain.StatsSummary, error) {
+	summary, err := s.repo.GetStatsSummary(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/maYkiss56/subscription-aggregation-service/internal/domain"
@@ -9,11 +10,14 @@ import (
 
 type SubRepository interface {
 	CreateSub(ctx context.Context, sub *domain.Sub) (id uuid.UUID, err error)
-	GetAllSubs(ctx context.Context) ([]*domain.Sub, error)
-	GetSubByUserID(ctx context.Context, userUID uuid.UUID) ([]*domain.Sub, error)
-	UpdateSub(ctx context.Context, id uuid.UUID, req *domain.UpdateSubRequest) (*domain.Sub, error)
-	DeleteSub(ctx context.Context, id uuid.UUID) error
-	CalculateTotalCost(ctx context.Context, filter domain.TotalCostFilter) (int, error)
+	GetAllSubs(ctx context.Context, params domain.ListSubsParams) (*domain.SubPage, error)
+	GetSubByUserID(ctx context.Context, userUID uuid.UUID, params domain.ListSubsParams) (*domain.SubPage, error)
+	GetSubByID(ctx context.Context, id uuid.UUID, includeDeleted bool) (*domain.Sub, error)
+	UpdateSub(ctx context.Context, id uuid.UUID, update func(sub *domain.Sub) error) (*domain.Sub, error)
+	DeleteSub(ctx context.Context, id uuid.UUID, versions []int) error
+	RestoreSub(ctx context.Context, id uuid.UUID) (*domain.Sub, error)
+	PurgeDeletedSubs(ctx context.Context, before time.Time) (int64, error)
+	CalculateTotalCost(ctx context.Context, filter domain.TotalCostFilter) (*domain.TotalCost, error)
 }
 
 type SubService struct {
@@ -35,17 +39,45 @@ func (s *SubService) CreateSub(ctx context.Context, sub *domain.Sub) (id uuid.UU
 	return id, nil
 }
 
-func (s *SubService) GetAllSubs(ctx context.Context) ([]*domain.Sub, error) {
-	subs, err := s.repo.GetAllSubs(ctx)
+func (s *SubService) GetAllSubs(ctx context.Context, params domain.ListSubsParams) (*domain.SubPage, error) {
+	page, err := s.repo.GetAllSubs(ctx, params)
 	if err != nil {
 		return nil, err
 	}
 
-	return subs, nil
+	return page, nil
 }
 
-func (s *SubService) GetSubByUserID(ctx context.Context, userUID uuid.UUID) ([]*domain.Sub, error) {
-	sub, err := s.repo.GetSubByUserID(ctx, userUID)
+func (s *SubService) GetSubByUserID(ctx context.Context, userUID uuid.UUID, params domain.ListSubsParams) (*domain.SubPage, error) {
+	page, err := s.repo.GetSubByUserID(ctx, userUID, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func (s *SubService) GetSubByID(ctx context.Context, id uuid.UUID, includeDeleted bool) (*domain.Sub, error) {
+	sub, err := s.repo.GetSubByID(ctx, id, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// UpdateSub обновляет подписку, если versions не пуст, только при совпадении её версии с одной из них
+func (s *SubService) UpdateSub(ctx context.Context, id uuid.UUID, req *domain.UpdateSubRequest, versions []int) (*domain.Sub, error) {
+	req.Normalize()
+
+	// Изменения проверяются относительно текущего состояния подписки внутри транзакции
+	sub, err := s.repo.UpdateSub(ctx, id, func(sub *domain.Sub) error {
+		if err := sub.CheckVersion(versions); err != nil {
+			return err
+		}
+
+		return sub.Apply(req)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -53,8 +85,15 @@ func (s *SubService) GetSubByUserID(ctx context.Context, userUID uuid.UUID) ([]*
 	return sub, nil
 }
 
-func (s *SubService) UpdateSub(ctx context.Context, id uuid.UUID, req *domain.UpdateSubRequest) (*domain.Sub, error) {
-	sub, err := s.repo.UpdateSub(ctx, id, req)
+// PauseSub ставит подписку на паузу с from по to включительно, to равный nil означает паузу до возобновления
+func (s *SubService) PauseSub(ctx context.Context, id uuid.UUID, from time.Time, to *time.Time, versions []int) (*domain.Sub, error) {
+	sub, err := s.repo.UpdateSub(ctx, id, func(sub *domain.Sub) error {
+		if err := sub.CheckVersion(versions); err != nil {
+			return err
+		}
+
+		return sub.Pause(from, to)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -62,19 +101,54 @@ func (s *SubService) UpdateSub(ctx context.Context, id uuid.UUID, req *domain.Up
 	return sub, nil
 }
 
-func (s *SubService) DeleteSub(ctx context.Context, id uuid.UUID) error {
-	if err := s.repo.DeleteSub(ctx, id); err != nil {
+// ResumeSub возобновляет подписку с даты at
+func (s *SubService) ResumeSub(ctx context.Context, id uuid.UUID, at time.Time, versions []int) (*domain.Sub, error) {
+	sub, err := s.repo.UpdateSub(ctx, id, func(sub *domain.Sub) error {
+		if err := sub.CheckVersion(versions); err != nil {
+			return err
+		}
+
+		return sub.Resume(at)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+func (s *SubService) DeleteSub(ctx context.Context, id uuid.UUID, versions []int) error {
+	if err := s.repo.DeleteSub(ctx, id, versions); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (s *SubService) CalculateTotalCost(ctx context.Context, filter domain.TotalCostFilter) (int, error) {
-	total, err := s.repo.CalculateTotalCost(ctx, filter)
+func (s *SubService) RestoreSub(ctx context.Context, id uuid.UUID) (*domain.Sub, error) {
+	sub, err := s.repo.RestoreSub(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// PurgeDeletedSubs окончательно удаляет подписки, помеченные удалёнными дольше retention назад
+func (s *SubService) PurgeDeletedSubs(ctx context.Context, retention time.Duration) (int64, error) {
+	purged, err := s.repo.PurgeDeletedSubs(ctx, time.Now().Add(-retention))
 	if err != nil {
 		return 0, err
 	}
 
+	return purged, nil
+}
+
+func (s *SubService) CalculateTotalCost(ctx context.Context, filter domain.TotalCostFilter) (*domain.TotalCost, error) {
+	total, err := s.repo.CalculateTotalCost(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
 	return total, nil
 }
@@ -5,33 +5,111 @@ import (
 	"time"
 )
 
+// DateFormat is the format of dates in requests and responses
+type DateFormat string
+
+const (
+	DateFormatDate      DateFormat = "YYYY-MM-DD"
+	DateFormatYearMonth DateFormat = "YYYY-MM"
+	DateFormatMonthYear DateFormat = "MM-YYYY"
+)
+
 const (
 	monthYearLayout = "01-2006"
+	yearMonthLayout = "2006-01"
+	dateLayout      = "2006-01-02"
 )
 
-func ParseMonthYear(dateStr string) (time.Time, error) {
-	if len(dateStr) != 7 || dateStr[2] != '-' {
-		return time.Time{}, errors.New("invalid date format, expected MM-YYYY")
+var errDateFormat = errors.New("invalid date format, expected YYYY-MM-DD, YYYY-MM or MM-YYYY")
+
+func (f DateFormat) Valid() bool {
+	switch f {
+	case DateFormatDate, DateFormatYearMonth, DateFormatMonthYear:
+		return true
+	default:
+		return false
+	}
+}
+
+// detectDateFormat определяет формат даты по расположению разделителей
+func detectDateFormat(dateStr string) (DateFormat, bool) {
+	switch {
+	case len(dateStr) == 10 && dateStr[4] == '-' && dateStr[7] == '-':
+		return DateFormatDate, true
+	case len(dateStr) == 7 && dateStr[4] == '-':
+		return DateFormatYearMonth, true
+	case len(dateStr) == 7 && dateStr[2] == '-':
+		return DateFormatMonthYear, true
+	default:
+		return "", false
+	}
+}
+
+// ParsePeriod разбирает дату в формате YYYY-MM-DD, YYYY-MM или MM-YYYY и возвращает
+// первый и последний день, которые она обозначает: для месяца это его первый и последний день
+func ParsePeriod(dateStr string) (from, to time.Time, format DateFormat, err error) {
+	format, ok := detectDateFormat(dateStr)
+	if !ok {
+		return time.Time{}, time.Time{}, "", errDateFormat
 	}
 
-	// Парсим дату как первый день месяца
-	parsed, err := time.Parse(monthYearLayout, dateStr)
+	switch format {
+	case DateFormatDate:
+		from, err = time.Parse(dateLayout, dateStr)
+		to = from
+	case DateFormatYearMonth:
+		from, err = time.Parse(yearMonthLayout, dateStr)
+		to = from.AddDate(0, 1, -1)
+	default:
+		from, err = time.Parse(monthYearLayout, dateStr)
+		to = from.AddDate(0, 1, -1)
+	}
 	if err != nil {
-		return time.Time{}, err
+		return time.Time{}, time.Time{}, "", errDateFormat
 	}
 
-	return parsed, nil
+	return from, to, format, nil
 }
 
-func ParseMonthYearToEndOfMonth(dateStr string) (time.Time, error) {
-	parsed, err := ParseMonthYear(dateStr)
-	if err != nil {
-		return time.Time{}, err
+// FormatDate форматирует дату в указанном формате, для месячных форматов день отбрасывается
+func FormatDate(date time.Time, format DateFormat) string {
+	switch format {
+	case DateFormatDate:
+		return date.Format(dateLayout)
+	case DateFormatYearMonth:
+		return date.Format(yearMonthLayout)
+	default:
+		return date.Format(monthYearLayout)
+	}
+}
+
+// FormatMonth форматирует месяц в месячном формате, соответствующем указанному
+func FormatMonth(month time.Time, format DateFormat) string {
+	if format == DateFormatDate {
+		format = DateFormatYearMonth
 	}
 
-	return parsed.AddDate(0, 1, -1), nil
+	return FormatDate(month, format)
+}
+
+func ParseDate(dateStr string) (time.Time, error) {
+	return time.Parse(dateLayout, dateStr)
 }
 
-func ToMonthYearString(date time.Time) string {
-	return date.Format(monthYearLayout)
+func ToDateString(date time.Time) string {
+	return date.Format(dateLayout)
+}
+
+// AddMonths прибавляет к дате месяцы так же, как PostgreSQL: если в итоговом месяце
+// нет такого дня, берётся его последний день
+func AddMonths(date time.Time, months int) time.Time {
+	first := time.Date(date.Year(), date.Month()+time.Month(months), 1, 0, 0, 0, 0, date.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+
+	day := date.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return time.Date(first.Year(), first.Month(), day, date.Hour(), date.Minute(), date.Second(), date.Nanosecond(), date.Location())
 }
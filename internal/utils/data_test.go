@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParsePeriod(t *testing.T) {
+	tests := []struct {
+		name       string
+		in         string
+		wantFrom   time.Time
+		wantTo     time.Time
+		wantFormat DateFormat
+		wantErr    bool
+	}{
+		{name: "date", in: "2025-03-15", wantFrom: date(2025, 3, 15), wantTo: date(2025, 3, 15), wantFormat: DateFormatDate},
+		{name: "year and month", in: "2025-04", wantFrom: date(2025, 4, 1), wantTo: date(2025, 4, 30), wantFormat: DateFormatYearMonth},
+		{name: "month and year", in: "07-2025", wantFrom: date(2025, 7, 1), wantTo: date(2025, 7, 31), wantFormat: DateFormatMonthYear},
+		{name: "february", in: "02-2025", wantFrom: date(2025, 2, 1), wantTo: date(2025, 2, 28), wantFormat: DateFormatMonthYear},
+		{name: "leap february", in: "2024-02", wantFrom: date(2024, 2, 1), wantTo: date(2024, 2, 29), wantFormat: DateFormatYearMonth},
+		{name: "december", in: "12-2025", wantFrom: date(2025, 12, 1), wantTo: date(2025, 12, 31), wantFormat: DateFormatMonthYear},
+		{name: "invalid day", in: "2025-02-30", wantErr: true},
+		{name: "invalid month", in: "13-2025", wantErr: true},
+		{name: "unknown layout", in: "2025/03/15", wantErr: true},
+		{name: "empty", in: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, format, err := ParsePeriod(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePeriod(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if !from.Equal(tt.wantFrom) || !to.Equal(tt.wantTo) || format != tt.wantFormat {
+				t.Errorf("ParsePeriod(%q) = %s, %s, %s, want %s, %s, %s", tt.in,
+					ToDateString(from), ToDateString(to), format,
+					ToDateString(tt.wantFrom), ToDateString(tt.wantTo), tt.wantFormat)
+			}
+		})
+	}
+}
+func TestAddMonths(t *testing.T) {
+	tests := []struct {
+		name   string
+		date   time.Time
+		months int
+		want   time.Time
+	}{
+		{name: "same day", date: date(2025, 1, 17), months: 1, want: date(2025, 2, 17)},
+		{name: "zero months", date: date(2025, 1, 31), months: 0, want: date(2025, 1, 31)},
+		{name: "end of january to february", date: date(2025, 1, 31), months: 1, want: date(2025, 2, 28)},
+		{name: "end of january to leap february", date: date(2024, 1, 31), months: 1, want: date(2024, 2, 29)},
+		{name: "end of january to march", date: date(2025, 1, 31), months: 2, want: date(2025, 3, 31)},
+		{name: "31st to 30-day month", date: date(2025, 3, 31), months: 1, want: date(2025, 4, 30)},
+		{name: "leap day to next year", date: date(2024, 2, 29), months: 12, want: date(2025, 2, 28)},
+		{name: "across year", date: date(2025, 11, 30), months: 3, want: date(2026, 2, 28)},
+		{name: "backwards", date: date(2025, 3, 31), months: -1, want: date(2025, 2, 28)},
+		{name: "clamped day is not carried over", date: date(2025, 1, 31), months: 3, want: date(2025, 4, 30)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AddMonths(tt.date, tt.months); !got.Equal(tt.want) {
+				t.Errorf("AddMonths(%s, %d) = %s, want %s", ToDateString(tt.date), tt.months, ToDateString(got), ToDateString(tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_subscriptions_user_service_name_id;
+DROP INDEX IF EXISTS idx_subscriptions_user_price_id;
+DROP INDEX IF EXISTS idx_subscriptions_user_start_date_id;
+
+DROP INDEX IF EXISTS idx_subscriptions_service_name_id;
+DROP INDEX IF EXISTS idx_subscriptions_price_id;
+DROP INDEX IF EXISTS idx_subscriptions_start_date_id;
@@ -0,0 +1,7 @@
+CREATE INDEX IF NOT EXISTS idx_subscriptions_start_date_id ON subscriptions (start_date, id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_price_id ON subscriptions (price, id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_service_name_id ON subscriptions (service_name, id);
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_start_date_id ON subscriptions (user_id, start_date, id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_price_id ON subscriptions (user_id, price, id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_service_name_id ON subscriptions (user_id, service_name, id);
@@ -0,0 +1,6 @@
+ALTER TABLE subscriptions
+    DROP CONSTRAINT IF EXISTS valid_billing_interval,
+    DROP CONSTRAINT IF EXISTS valid_billing_period,
+    DROP COLUMN IF EXISTS billing_anchor,
+    DROP COLUMN IF EXISTS billing_interval,
+    DROP COLUMN IF EXISTS billing_period;
@@ -0,0 +1,15 @@
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS billing_period VARCHAR(32) NOT NULL DEFAULT 'monthly',
+    ADD COLUMN IF NOT EXISTS billing_interval INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS billing_anchor DATE NULL;
+
+UPDATE subscriptions SET billing_anchor = start_date WHERE billing_anchor IS NULL;
+
+ALTER TABLE subscriptions
+    ALTER COLUMN billing_anchor SET NOT NULL,
+    ADD CONSTRAINT valid_billing_period CHECK (
+        billing_period IN ('weekly', 'monthly', 'quarterly', 'yearly', 'every_n_months')
+    ),
+    ADD CONSTRAINT valid_billing_interval CHECK (
+        billing_interval > 0 AND (billing_period = 'every_n_months' OR billing_interval = 1)
+    );
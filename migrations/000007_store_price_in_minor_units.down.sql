@@ -0,0 +1,9 @@
+UPDATE subscriptions
+SET price_minor = GREATEST(1, ROUND(price_minor::numeric / CASE
+    WHEN currency IN ('CLP', 'ISK', 'JPY', 'KRW', 'VND') THEN 1
+    WHEN currency IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 1000
+    ELSE 100
+END));
+
+ALTER TABLE subscriptions ALTER COLUMN price_minor TYPE INTEGER;
+ALTER TABLE subscriptions RENAME COLUMN price_minor TO price;
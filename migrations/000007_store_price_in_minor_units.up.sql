@@ -0,0 +1,9 @@
+ALTER TABLE subscriptions RENAME COLUMN price TO price_minor;
+ALTER TABLE subscriptions ALTER COLUMN price_minor TYPE BIGINT;
+
+UPDATE subscriptions
+SET price_minor = price_minor * CASE
+    WHEN currency IN ('CLP', 'ISK', 'JPY', 'KRW', 'VND') THEN 1
+    WHEN currency IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 1000
+    ELSE 100
+END;
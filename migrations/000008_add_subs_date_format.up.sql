@@ -0,0 +1,3 @@
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS date_format VARCHAR(10) NOT NULL DEFAULT 'MM-YYYY'
+        CONSTRAINT valid_date_format CHECK (date_format IN ('YYYY-MM-DD', 'YYYY-MM', 'MM-YYYY'));
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS subscription_phases (
+    sub_id UUID NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL CHECK (position >= 0),
+    kind VARCHAR(16) NOT NULL CHECK (kind IN ('trial', 'promo')),
+    price_minor BIGINT NOT NULL CHECK (price_minor >= 0),
+    months INTEGER NOT NULL CHECK (months > 0),
+
+    PRIMARY KEY (sub_id, position),
+    CONSTRAINT free_trial CHECK (kind <> 'trial' OR price_minor = 0)
+);
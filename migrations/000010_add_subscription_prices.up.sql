@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS subscription_prices (
+    sub_id UUID NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    effective_from DATE NOT NULL,
+    price_minor BIGINT NOT NULL CHECK (price_minor > 0),
+
+    PRIMARY KEY (sub_id, effective_from)
+);
+
+INSERT INTO subscription_prices (sub_id, effective_from, price_minor)
+SELECT id, start_date, price_minor FROM subscriptions
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS subscription_pauses (
+    sub_id UUID NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    paused_from DATE NOT NULL,
+    paused_to DATE NULL,
+
+    PRIMARY KEY (sub_id, paused_from),
+    CONSTRAINT valid_pause_dates CHECK (paused_to IS NULL OR paused_to >= paused_from)
+);
@@ -0,0 +1,49 @@
+CREATE TABLE IF NOT EXISTS services (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    category VARCHAR(64) NULL,
+    homepage VARCHAR(2048) NULL,
+    default_price_minor BIGINT NULL CHECK (default_price_minor > 0),
+    default_currency CHAR(3) NULL CHECK (default_currency ~ '^[A-Z]{3}$'),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT default_price_currency CHECK ((default_price_minor IS NULL) = (default_currency IS NULL))
+);
+
+-- Каноничные имена и псевдонимы сервисов в нормализованном виде: в нижнем регистре,
+-- без пробелов по краям и с одиночными пробелами между словами. Первичный ключ не даёт
+-- двум сервисам претендовать на одно имя
+CREATE TABLE IF NOT EXISTS service_names (
+    normalized_name VARCHAR(255) PRIMARY KEY,
+    service_id UUID NOT NULL REFERENCES services (id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    is_alias BOOLEAN NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_names_service_id ON service_names (service_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_service_names_canonical ON service_names (service_id) WHERE NOT is_alias;
+
+-- Для каждого нормализованного имени подписок заводим сервис с самым частым написанием
+INSERT INTO services (name)
+SELECT mode() WITHIN GROUP (ORDER BY regexp_replace(btrim(service_name), '\s+', ' ', 'g'))
+FROM subscriptions
+GROUP BY lower(regexp_replace(btrim(service_name), '\s+', ' ', 'g'));
+
+INSERT INTO service_names (normalized_name, service_id, name, is_alias)
+SELECT lower(name), id, name, false
+FROM services;
+
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS service_id UUID NULL REFERENCES services (id);
+
+UPDATE subscriptions s
+SET service_id = n.service_id
+FROM service_names n
+WHERE n.normalized_name = lower(regexp_replace(btrim(s.service_name), '\s+', ' ', 'g'));
+
+ALTER TABLE subscriptions
+    ALTER COLUMN service_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_service_id ON subscriptions (service_id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_service_id ON subscriptions (user_id, service_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_service_names_normalized_name_trgm;
+
+DROP EXTENSION IF EXISTS pg_trgm;
@@ -0,0 +1,5 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Индекс для поиска похожих имён сервисов операторами pg_trgm и для поиска по префиксу
+CREATE INDEX IF NOT EXISTS idx_service_names_normalized_name_trgm
+    ON service_names USING gin (normalized_name gin_trgm_ops);
@@ -0,0 +1,28 @@
+-- Категории приводятся к нижнему регистру с одиночными пробелами, как в domain.NormalizeCategory
+UPDATE services
+SET category = lower(regexp_replace(btrim(category), '\s+', ' ', 'g'))
+WHERE category IS NOT NULL;
+
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS category VARCHAR(64) NULL;
+
+UPDATE subscriptions s
+SET category = sv.category
+FROM services sv
+WHERE sv.id = s.service_id AND sv.category IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_category ON subscriptions (category);
+
+CREATE TABLE IF NOT EXISTS tags (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    name VARCHAR(64) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS subscription_tags (
+    sub_id UUID NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    tag_id BIGINT NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+
+    PRIMARY KEY (sub_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_tags_tag_id ON subscription_tags (tag_id);